@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/CodeZeroSugar/go-scan/internal/checkpoint"
+	"github.com/CodeZeroSugar/go-scan/internal/paths"
+)
+
+// CheckpointInterval is how often a port scan saves its progress.
+const CheckpointInterval = 5 * time.Second
+
+// newCheckpoint plans a scan of ports on hosts, saved under the config
+// directory. If there is nowhere to save it the scan still runs, it just
+// can't be resumed.
+func newCheckpoint(started time.Time, hosts []string, ports []int) *checkpoint.Checkpoint {
+	path, err := paths.CheckpointPath(started)
+	if err != nil {
+		log.Printf("failed to create checkpoint directory, scan won't be resumable: %s", err)
+		path = ""
+	}
+	return checkpoint.New(path, os.Args[1:], slices.Clone(hosts), ports)
+}
+
+// checkpointHosts returns the hosts a checkpoint plans to scan.
+func checkpointHosts(cp *checkpoint.Checkpoint) ([]net.IP, error) {
+	hosts := make([]net.IP, 0, len(cp.Hosts))
+	for _, h := range cp.Hosts {
+		ip := net.ParseIP(h)
+		if ip == nil {
+			return nil, fmt.Errorf("checkpoint has an invalid host '%s'", h)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+		}
+		hosts = append(hosts, ip)
+	}
+	return hosts, nil
+}
+
+func saveCheckpoint(cp *checkpoint.Checkpoint) {
+	if err := cp.Save(); err != nil {
+		log.Printf("failed to save checkpoint: %s", err)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+
+// displayState reports whether a port in state should be printed. Open,
+// Open|Filtered and (for ACK scans) Unfiltered ports are always shown, plain
+// filtered ports only when asked for.
+func displayState(state tcpscanner.PortState, filtered bool) bool {
+	switch state {
+	case tcpscanner.Open, tcpscanner.OpenFiltered, tcpscanner.Unfiltered:
+		return true
+	case tcpscanner.Filtered:
+		return filtered
+	default:
+		return false
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+	icmpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/icmp_scanner"
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+// exclusions combines -exclude and -excludefile into the addresses to leave
+// out of the scan, or nil if neither is set.
+func exclusions(ctx context.Context, params tcpscanner.Params, dns *resolver.Resolver) (*icmpscanner.Exclusions, error) {
+	var specs []string
+	if params.Exclude != "" {
+		specs = append(specs, params.Exclude)
+	}
+
+	if params.ExcludeFile != "" {
+		f, err := os.Open(params.ExcludeFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open exclude file: %w", err)
+		}
+		defer f.Close()
+
+		spec, err := icmpscanner.ReadTargets(f)
+		if err != nil {
+			return nil, err
+		}
+		if spec != "" {
+			specs = append(specs, spec)
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	return icmpscanner.ParseExclusions(ctx, strings.Join(specs, ","), dns)
+}
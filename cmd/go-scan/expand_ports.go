@@ -0,0 +1,15 @@
+package main
+
+import tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+
+func expandPorts(params tcpscanner.Params, p []int) []int {
+	if params.PortMode != tcpscanner.Series {
+		return p
+	}
+
+	var ports []int
+	for port := p[0]; port < p[1]; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
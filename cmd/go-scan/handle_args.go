@@ -4,32 +4,200 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"log"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/CodeZeroSugar/go-scan/internal/checkpoint"
+	"github.com/CodeZeroSugar/go-scan/internal/config"
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+	synscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/syn_scanner"
 	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
 	"github.com/CodeZeroSugar/go-scan/internal/stats"
 )
 
-func handleFlags() tcpscanner.Params {
+func handleFlags() (tcpscanner.Params, *checkpoint.Checkpoint) {
 	var params tcpscanner.Params
 	var targetVar string
+	var targetListVar string
+	var excludeVar string
+	var excludeFileVar string
 	var portsVar string
 	var snVar bool
 	var statsVar bool
 	var filteredVar bool
-	flag.StringVar(&targetVar, "t", "127.0.0.1", "The IP Address you want to scan. Defaults to loopback.")
+	var synVar bool
+	var finVar bool
+	var nullVar bool
+	var xmasVar bool
+	var ackVar bool
+	var windowVar bool
+	var maimonVar bool
+	var sourceVar string
+	var ifaceVar string
+	var retriesVar int
+	var pcapVar string
+	var minRateVar float64
+	var maxRateVar float64
+	var workersVar int
+	var scanDelayVar time.Duration
+	var pingTimeoutVar time.Duration
+	var connectTimeoutVar time.Duration
+	var hostTimeoutVar time.Duration
+	var templateVars [len(config.Templates)]bool
+	var resumeVar string
+	var dnsServerVar string
+	var resolveFamilyVar string
+	var dnsTimeoutVar time.Duration
+	var rdnsVar bool
+	flag.StringVar(&targetVar, "t", "127.0.0.1", "The IP Address you want to scan. Defaults to loopback.\nIPv4 and IPv6 addresses, ranges and CIDRs are accepted, up to a /8 (IPv4) or /112 (IPv6) each,\nas are hostnames, which are scanned at every address they resolve to.")
+	flag.StringVar(&targetListVar, "iL", "", "Read targets from this file, or stdin if '-', instead of -t.\nTargets go one or more per line in any form -t accepts.\nBlank lines and anything after a # are ignored.")
+	flag.StringVar(&excludeVar, "exclude", "", "Targets to leave out of the scan, in the same forms -t accepts.")
+	flag.StringVar(&excludeFileVar, "excludefile", "", "File listing targets to leave out of the scan, one or more per line.\nBlank lines and anything after a # are ignored.")
 	flag.StringVar(&portsVar, "p", "1-1023", "Input a single port to scan only that port.\nSeparate ports with commas (no spaces) to scan those specific ports (22,54,80).\nProvide a range like '1-500' to scan all ports in that range.\nDefault is common ports.")
 	flag.BoolVar(&snVar, "sn", false, "Toggle for discovery scan only.\nStandard scan uses discovery by default.\nUsing this flag will disable port scanning and only ping hosts specified by -t flag.")
 	flag.BoolVar(&statsVar, "stats", false, "Display port stats. Cannot be used with other flags.\nOptions: top <n>, all\n")
 	flag.BoolVar(&filteredVar, "f", false, "Display filtered ports. Only open ports are displayed by default.")
+	flag.BoolVar(&synVar, "sS", false, "TCP SYN (half-open) scan. Requires root privileges.\nDefault is a full TCP connect scan.")
+	flag.BoolVar(&finVar, "sF", false, "TCP FIN scan. Requires root privileges.")
+	flag.BoolVar(&nullVar, "sN", false, "TCP NULL scan (no flags set). Requires root privileges.")
+	flag.BoolVar(&xmasVar, "sX", false, "TCP Xmas scan (FIN, PSH and URG set). Requires root privileges.")
+	flag.BoolVar(&ackVar, "sA", false, "TCP ACK scan. Reports ports as unfiltered or filtered to map firewall rules.\nRequires root privileges.")
+	flag.BoolVar(&windowVar, "sW", false, "TCP Window scan. An ACK scan that reads the RST window size to tell open from closed.\nRequires root privileges.")
+	flag.BoolVar(&maimonVar, "sM", false, "TCP Maimon scan (FIN and ACK set). Requires root privileges.")
+	flag.StringVar(&sourceVar, "S", "", "Source IP address for raw packet scans.\nDefaults to the address the routing table picks for each target.")
+	flag.StringVar(&ifaceVar, "e", "", "Network interface to send raw packet scans from.")
+	flag.StringVar(&pcapVar, "pcap", "", "Write every packet sent and received by a raw packet scan to this pcap file.")
+	flag.Float64Var(&minRateVar, "min-rate", 0, "Send at least this many probes per second.\nConnect scans start enough workers to keep up with it.\nRaw packet scans send past full congestion windows when they fall behind it.")
+	flag.Float64Var(&maxRateVar, "max-rate", 0, "Send at most this many probes per second across discovery and port scanning.\nDefault is unlimited.")
+	flag.IntVar(&retriesVar, "max-retries", synscanner.DefaultRetries, "Number of times an unanswered probe or timed out connection is retried before the port is reported filtered.\nConnect scans only retry when this is set.")
+	flag.IntVar(&workersVar, "workers", 100, "Number of concurrent connect scan workers.")
+	flag.DurationVar(&scanDelayVar, "scan-delay", 0, "Wait at least this long between probes, e.g. 500ms.")
+	flag.DurationVar(&pingTimeoutVar, "ping-timeout", time.Second, "How long to wait for each discovery ping reply.")
+	flag.DurationVar(&connectTimeoutVar, "connect-timeout", 2*time.Second, "Longest a connect scan waits for each connection.\nWith -max-retries, shorter timeouts are used once a host's round-trip time is known.")
+	flag.DurationVar(&hostTimeoutVar, "host-timeout", 0, "Give up on a host's remaining ports this long after its first probe, e.g. 5m.\nDefault is no limit.")
+	for i, t := range config.Templates {
+		usage := fmt.Sprintf("Timing template %q. Other timing flags override its settings.", t.Name)
+		if i == config.DefaultTemplate {
+			usage += "\nUsed by default."
+		}
+		flag.BoolVar(&templateVars[i], fmt.Sprintf("T%d", i), false, usage)
+	}
+
+	flag.StringVar(&dnsServerVar, "dns-server", "", "DNS server to resolve hostnames with, as an IP with an optional port.\nDefault is the system resolver.")
+	flag.DurationVar(&dnsTimeoutVar, "dns-timeout", resolver.DefaultTimeout, "How long to wait for each DNS lookup.")
+	flag.BoolVar(&rdnsVar, "rdns", false, "Look up the PTR name of every live host and show it with the host's results.")
+	flag.StringVar(&resolveFamilyVar, "resolve-family", "both", "Which addresses hostnames resolve to: 4, 6 or both.")
+	flag.StringVar(&resumeVar, "resume", "", "Continue an interrupted scan from the checkpoint file it saved.\nCannot be used with other flags.")
 
 	flag.Parse()
+
+	var resume *checkpoint.Checkpoint
+	if resumeVar != "" {
+		if flag.NFlag() > 1 {
+			log.Fatalf("-resume cannot be used with other flags")
+		}
+		cp, err := checkpoint.Load(resumeVar)
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+		if err := flag.CommandLine.Parse(cp.Args); err != nil {
+			log.Fatalf("failed to parse checkpoint arguments: %s", err)
+		}
+		resume = cp
+	}
+
 	params.Target = targetVar
+	// A resumed scan already knows its hosts, so its list isn't read again.
+	if targetListVar != "" && resume == nil {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "t" {
+				log.Fatalf("-t and -iL cannot be used together")
+			}
+		})
+		spec, err := readTargetList(targetListVar)
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+		params.Target = spec
+	}
+	params.Exclude = excludeVar
+	params.ExcludeFile = excludeFileVar
 	params.Stats = statsVar
 	params.Discovery = snVar
 	params.Filtered = filteredVar
+	params.SourceIP = sourceVar
+	params.Interface = ifaceVar
+	params.PcapFile = pcapVar
+	params.DNSServer = dnsServerVar
+	params.DNSTimeout = dnsTimeoutVar
+	params.ReverseDNS = rdnsVar
+
+	family, err := resolver.ParseFamily(resolveFamilyVar)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+	params.ResolveFamily = family
+
+	level := config.DefaultTemplate
+	chosen := 0
+	for i, set := range templateVars {
+		if set {
+			level = i
+			chosen++
+		}
+	}
+	if chosen > 1 {
+		log.Fatalf("only one timing template (-T0 to -T5) can be used at a time")
+	}
+	params.Timing, _ = config.Template(level)
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "max-retries":
+			params.Timing.Retries = retriesVar
+			params.Timing.ConnectRetries = retriesVar
+		case "min-rate":
+			params.Timing.MinRate = minRateVar
+		case "max-rate":
+			params.Timing.MaxRate = maxRateVar
+		case "workers":
+			params.Timing.Workers = workersVar
+		case "scan-delay":
+			params.Timing.ScanDelay = scanDelayVar
+		case "ping-timeout":
+			params.Timing.PingTimeout = pingTimeoutVar
+		case "connect-timeout":
+			params.Timing.DialTimeout = connectTimeoutVar
+		case "host-timeout":
+			params.Timing.HostTimeout = hostTimeoutVar
+		}
+	})
+
+	scanTypes := []struct {
+		set      bool
+		scanType tcpscanner.ScanType
+	}{
+		{synVar, tcpscanner.SYN},
+		{finVar, tcpscanner.FIN},
+		{nullVar, tcpscanner.NULL},
+		{xmasVar, tcpscanner.Xmas},
+		{ackVar, tcpscanner.ACK},
+		{windowVar, tcpscanner.Window},
+		{maimonVar, tcpscanner.Maimon},
+	}
+	selected := 0
+	for _, st := range scanTypes {
+		if st.set {
+			params.ScanType = st.scanType
+			selected++
+		}
+	}
+	if selected > 1 {
+		log.Fatalf("only one of -sS, -sF, -sN, -sX, -sA, -sW and -sM can be used at a time")
+	}
 
 	if strings.Contains(portsVar, ",") {
 		params.PortMode = tcpscanner.Selection
@@ -48,7 +216,7 @@ func handleFlags() tcpscanner.Params {
 		params.Ports = append(params.Ports, portsVar)
 	}
 
-	return params
+	return params, resume
 }
 
 func handleStats(args []string, statPath string) error {
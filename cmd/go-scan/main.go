@@ -1,22 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
 	"sort"
+	"syscall"
 	"time"
 
 	"github.com/CodeZeroSugar/go-scan/internal/paths"
+	"github.com/CodeZeroSugar/go-scan/internal/pcap"
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
 	icmpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/icmp_scanner"
+	synscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/syn_scanner"
 	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
 	"github.com/CodeZeroSugar/go-scan/internal/stats"
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
 )
 
 const (
 	Version = "1.0.0"
 	URL     = "https://github.com/CodeZeroSugar/go-scan"
-	Workers = 100
 )
 
 func main() {
@@ -29,7 +40,7 @@ func main() {
 		log.Printf("failed to validate path to stats file: %s", err)
 	}
 
-	params := handleFlags()
+	params, resume := handleFlags()
 
 	if params.Stats {
 		args := flag.Args()
@@ -42,11 +53,47 @@ func main() {
 
 	ip := params.Target
 
+	if err := checkTiming(params.Timing); err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	limiter, err := rateLimiter(params)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	// The first Ctrl-C stops the scan and reports what it found so far; a
+	// second one, once stop has run, kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dns, err := resolver.New(params.DNSServer, params.ResolveFamily, params.DNSTimeout)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	exclude, err := exclusions(ctx, params, dns)
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
+
+	discoveryOpts := icmpscanner.Options{
+		Workers:  params.Timing.DiscoveryWorkers,
+		Timeout:  params.Timing.PingTimeout,
+		Limiter:  limiter,
+		Resolver: dns,
+		Exclude:  exclude,
+	}
+
 	if params.Discovery {
 		fmt.Println("Performing host discovery scan...")
-		hostsUp, err := icmpscanner.DiscoveryScan(ip)
-		if err != nil {
+		hostsUp, err := icmpscanner.DiscoveryScan(ctx, ip, discoveryOpts)
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("Host discovery interrupted, showing hosts found so far")
+		} else if err != nil {
 			log.Fatalf("%s", err)
+		} else if params.ReverseDNS {
+			icmpscanner.ReverseLookup(ctx, hostsUp, dns)
 		}
 
 		fmt.Println("Hosts up:")
@@ -62,62 +109,204 @@ func main() {
 		log.Fatalf("%s", err)
 	}
 
-	taskQueue := make(chan tcpscanner.PortScanTask)
+	ports := expandPorts(params, p)
 	taskResults := make(chan tcpscanner.PortScanResults, portLen)
 
-	for i := 0; i < Workers; i++ {
-		go tcpscanner.Scan(taskQueue, taskResults)
-	}
+	// A resumed scan keeps the hosts and ports it planned the first time
+	// rather than rediscovering them, so its checkpoint still lines up.
+	var hostsUp []net.IP
+	var hosts []string
+	names := make(map[string]string)
+	cp := resume
+	if cp != nil {
+		hostsUp, err = checkpointHosts(cp)
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+		hosts = slices.Clone(cp.Hosts)
+		ports = cp.Ports
+		maps.Copy(names, cp.Names)
+		fmt.Printf("Resuming scan from %s: %d port(s) left to scan\n", cp.Path(), cp.Remaining())
 
-	hostsUp, err := icmpscanner.DiscoveryScan(ip)
-	if err != nil {
-		log.Fatalf("%s", err)
-	}
+		if params.PcapFile != "" {
+			fmt.Printf("Not writing %s: it would overwrite the packets captured before the interruption\n", params.PcapFile)
+			params.PcapFile = ""
+		}
+	} else {
+		targets, err := icmpscanner.DiscoveryScan(ctx, ip, discoveryOpts)
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("Scan interrupted during host discovery")
+			return
+		} else if err != nil {
+			log.Fatalf("%s", err)
+		}
+		if params.ReverseDNS {
+			icmpscanner.ReverseLookup(ctx, targets, dns)
+		}
 
-	var hosts []string
-	for _, h := range hostsUp {
-		hosts = append(hosts, h.String())
+		for _, t := range targets {
+			hostsUp = append(hostsUp, t.IP)
+			hosts = append(hosts, t.IP.String())
+			if t.Hostname != "" {
+				names[t.IP.String()] = t.Hostname
+			}
+		}
+		cp = newCheckpoint(now, hosts, ports)
+		cp.Names = names
 	}
+	skip := cp.Skip()
 
-	totalTasks := 0
-	for range hostsUp {
-		totalTasks += portLen
-	}
+	totalTasks := len(hostsUp) * len(ports)
 
-	go func() {
-		for _, ip := range hostsUp {
-			if params.PortMode == tcpscanner.Series {
-				for port := p[0]; port < p[1]; port++ {
-					taskQueue <- tcpscanner.PortScanTask{
-						TargetIP: ip.To4(),
-						Port:     port,
+	// done is closed once every scanner goroutine has returned, so nothing
+	// is still sending or recording when results are printed.
+	done := make(chan struct{})
+
+	var capture *pcap.Writer
+	if params.ScanType != tcpscanner.Connect {
+		opts, err := synOptions(params)
+		if err != nil {
+			log.Fatalf("%s", err)
+		}
+		opts.Limiter = limiter
+		opts.Skip = skip
+		opts.Congestion = timing.NewCongestion(synscanner.MaxWindow)
+
+		if params.PcapFile != "" {
+			capture, err = pcap.Create(params.PcapFile)
+			if err != nil {
+				log.Fatalf("%s", err)
+			}
+			opts.Recorder = capture
+		}
+
+		go func() {
+			defer close(done)
+			err := synscanner.Scan(ctx, hostsUp, ports, opts, taskResults)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				log.Fatalf("raw packet scan failed: %s", err)
+			}
+		}()
+	} else {
+		taskQueue := make(chan tcpscanner.PortScanTask)
+
+		workers := connectWorkers(params)
+		rtt := connectRTT(params)
+		connOpts := tcpscanner.Options{
+			DialTimeout: params.Timing.DialTimeout,
+			Limiter:     limiter,
+			Congestion:  timing.NewCongestion(workers),
+			RTT:         rtt,
+			Hosts:       timing.NewHostClock(params.Timing.HostTimeout),
+			Retries:     params.Timing.ConnectRetries,
+		}
+		go func() {
+			defer close(done)
+			tcpscanner.Scan(ctx, workers, taskQueue, taskResults, connOpts)
+		}()
+
+		// Interleave hosts so one host's congestion window doesn't hold up
+		// workers that could be probing the others.
+		go func() {
+			defer close(taskQueue)
+			for _, port := range ports {
+				for _, ip := range hostsUp {
+					if skip(ip, port) {
+						continue
 					}
-				}
-			} else {
-				for _, port := range p {
-					taskQueue <- tcpscanner.PortScanTask{
-						TargetIP: ip.To4(),
+					task := tcpscanner.PortScanTask{
+						TargetIP: ip,
 						Port:     port,
+						Timeout:  rtt.Timeout(ip.String()),
+					}
+					select {
+					case taskQueue <- task:
+					case <-ctx.Done():
+						return
 					}
 				}
 			}
-		}
-		close(taskQueue)
-	}()
+		}()
+	}
 
 	resultsByHost := make(map[string][]tcpscanner.PortScanResults)
 	openPortsByHost := make(map[string][]int)
+	osByHost := make(map[string]string)
+	abandonedByHost := make(map[string]int)
+	scanned := 0
 
-	for i := 0; i < totalTasks; i++ {
-		res := <-taskResults
+	show := func(res tcpscanner.PortScanResults) {
+		scanned++
 		host := res.TargetIP.String()
 
-		if res.State == tcpscanner.Open || (res.State == tcpscanner.Filtered && params.Filtered) {
+		if displayState(res.State, params.Filtered) {
 			resultsByHost[host] = append(resultsByHost[host], res)
+		}
+		if res.OS != "" {
+			osByHost[host] = res.OS
+		}
+		if res.State == tcpscanner.Abandoned {
+			abandonedByHost[host]++
+		}
+	}
+
+	// Only ports found open in this run count towards the stats file; the
+	// interrupted run already counted the rest.
+	record := func(res tcpscanner.PortScanResults) {
+		show(res)
+		cp.Record(res)
+		if res.State == tcpscanner.Open {
+			host := res.TargetIP.String()
 			openPortsByHost[host] = append(openPortsByHost[host], res.Port)
 		}
 	}
 
+	for _, res := range cp.Results {
+		show(res.PortScanResults())
+	}
+
+	if cp.Path() != "" {
+		fmt.Printf("Saving scan progress to %s\n", cp.Path())
+	}
+	ticker := time.NewTicker(CheckpointInterval)
+	defer ticker.Stop()
+
+	interrupted := false
+	for scanned < totalTasks && !interrupted {
+		select {
+		case res := <-taskResults:
+			record(res)
+		case <-ticker.C:
+			saveCheckpoint(cp)
+		case <-ctx.Done():
+			interrupted = true
+		}
+	}
+
+	if interrupted {
+		stop()
+		fmt.Println("\nInterrupted, collecting results so far (Ctrl-C again to quit)")
+		collectRemaining(taskResults, done, record)
+		fmt.Printf("Scan interrupted: %d of %d ports scanned\n", scanned, totalTasks)
+
+		saveCheckpoint(cp)
+		if cp.Path() != "" {
+			fmt.Printf("Resume with: go-scan -resume %s\n", cp.Path())
+		}
+		fmt.Println()
+	} else if err := cp.Remove(); err != nil {
+		log.Printf("%s", err)
+	}
+
+	// The raw scanner can still be writing a late reply or RST after its
+	// last result, so the capture is only closed once it has returned.
+	<-done
+	if capture != nil {
+		if err := capture.Close(); err != nil {
+			log.Printf("%s", err)
+		}
+	}
+
 	sortHosts(hosts)
 	for _, h := range hosts {
 		results := resultsByHost[h]
@@ -125,7 +314,14 @@ func main() {
 			return results[i].Port < results[j].Port
 		})
 
-		fmt.Printf("Scan Results for: %s\n", h)
+		target := icmpscanner.Target{IP: net.ParseIP(h), Hostname: names[h]}
+		fmt.Printf("Scan Results for: %s\n", target)
+		if guess, ok := osByHost[h]; ok {
+			fmt.Printf("OS guess: %s\n", guess)
+		}
+		if n := abandonedByHost[h]; n > 0 {
+			fmt.Printf("Host timed out after %s: %d port(s) not scanned\n", params.Timing.HostTimeout, n)
+		}
 		if len(results) == 0 {
 			fmt.Printf("- No accessible ports detected\n\n")
 			continue
@@ -147,3 +343,23 @@ func main() {
 	d := time.Since(now)
 	fmt.Printf("GoScan done: %d host(s) scanned in %.2f seconds\n", len(resultsByHost), d.Seconds())
 }
+
+// collectRemaining records results until every scanner has stopped, then
+// whatever is still buffered.
+func collectRemaining(results chan tcpscanner.PortScanResults, done chan struct{}, record func(tcpscanner.PortScanResults)) {
+	for {
+		select {
+		case res := <-results:
+			record(res)
+		case <-done:
+			for {
+				select {
+				case res := <-results:
+					record(res)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/CodeZeroSugar/go-scan/internal/config"
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
+)
+
+// checkTiming rejects timing settings no scan could run with.
+func checkTiming(t config.Timing) error {
+	switch {
+	case t.MinRate < 0 || t.MaxRate < 0:
+		return fmt.Errorf("-min-rate and -max-rate cannot be negative")
+	case t.ScanDelay < 0:
+		return fmt.Errorf("-scan-delay cannot be negative")
+	case t.DialTimeout <= 0:
+		return fmt.Errorf("-connect-timeout must be positive")
+	case t.PingTimeout <= 0:
+		return fmt.Errorf("-ping-timeout must be positive")
+	case t.HostTimeout < 0:
+		return fmt.Errorf("-host-timeout cannot be negative")
+	case t.Retries < 0:
+		return fmt.Errorf("-max-retries cannot be negative")
+	}
+	return nil
+}
+
+// rateLimiter returns the limiter shared by every scan engine, or nil if
+// no rate was asked for.
+func rateLimiter(params tcpscanner.Params) (*timing.Limiter, error) {
+	t := params.Timing
+	maxRate := t.EffectiveMaxRate()
+	if maxRate > 0 && t.MinRate > maxRate {
+		return nil, fmt.Errorf("-min-rate %g is above the %g probes per second allowed by -max-rate and -scan-delay", t.MinRate, maxRate)
+	}
+	if t.MinRate == 0 && maxRate == 0 {
+		return nil, nil
+	}
+
+	return timing.NewLimiter(t.MinRate, maxRate), nil
+}
+
+// connectWorkers returns how many connect scan workers to run. Each one
+// can take up to a full dial timeout per port, so reaching -min-rate
+// against unresponsive hosts needs enough of them in flight.
+func connectWorkers(params tcpscanner.Params) int {
+	t := params.Timing
+	needed := int(math.Ceil(t.MinRate * t.DialTimeout.Seconds()))
+	return max(t.Workers, needed, 1)
+}
+
+// connectRTT returns the estimator that shortens connect timeouts for
+// hosts that answer quickly. Until a host has answered it gets the full
+// -connect-timeout, which also caps every adapted timeout.
+//
+// A shortened timeout gives up before the kernel resends a lost SYN, so
+// it is only used when a timed out port gets retried. Without retries
+// every connection gets the full -connect-timeout.
+func connectRTT(params tcpscanner.Params) *timing.RTTEstimator {
+	rtt := timing.NewRTTEstimator()
+	rtt.Initial = params.Timing.DialTimeout
+	rtt.Max = params.Timing.DialTimeout
+	rtt.Min = min(rtt.Min, params.Timing.DialTimeout)
+	if params.Timing.ConnectRetries == 0 {
+		rtt.Min = params.Timing.DialTimeout
+	}
+	return rtt
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/CodeZeroSugar/go-scan/internal/config"
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lossyListener returns the address of a listener whose accept queue is
+// full, so the kernel drops the next SYN sent to it. Room is made shortly
+// after, in time for the client's first SYN retransmission a second later.
+func lossyListener(t *testing.T) *net.TCPAddr {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { syscall.Close(fd) })
+	require.NoError(t, syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}))
+	require.NoError(t, syscall.Listen(fd, 0))
+
+	sa, err := syscall.Getsockname(fd)
+	require.NoError(t, err)
+	addr := &net.TCPAddr{IP: net.IP{127, 0, 0, 1}, Port: sa.(*syscall.SockaddrInet4).Port}
+
+	// A backlog of 0 still queues one connection, which fills it.
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		if nfd, _, err := syscall.Accept(fd); err == nil {
+			syscall.Close(nfd)
+		}
+	}()
+	return addr
+}
+
+func TestConnectRTT(t *testing.T) {
+	host := "127.0.0.1"
+	params := tcpscanner.Params{Timing: config.Timing{DialTimeout: 2 * time.Second}}
+	rtt := connectRTT(params)
+	for range 10 {
+		rtt.Observe(host, time.Millisecond)
+	}
+
+	// Test: without retries a host that answers quickly still gets the
+	// full connect timeout
+	assert.Equal(t, 2*time.Second, rtt.Timeout(host))
+
+	// Test: a lossy host with a low round trip isn't reported filtered
+	// when its SYN is dropped
+	addr := lossyListener(t)
+	tasks := make(chan tcpscanner.PortScanTask, 1)
+	tasks <- tcpscanner.PortScanTask{TargetIP: addr.IP, Port: addr.Port, Timeout: rtt.Timeout(host)}
+	close(tasks)
+	results := make(chan tcpscanner.PortScanResults, 1)
+	tcpscanner.Scan(context.Background(), 1, tasks, results, tcpscanner.Options{DialTimeout: 2 * time.Second, RTT: rtt})
+	res := <-results
+	assert.Equal(t, tcpscanner.Open, res.State)
+	assert.Equal(t, 1, res.Attempts)
+
+	// Test: with retries the timeout adapts to the host's round trip
+	params.Timing.ConnectRetries = 1
+	rtt = connectRTT(params)
+	for range 10 {
+		rtt.Observe(host, time.Millisecond)
+	}
+	assert.Less(t, rtt.Timeout(host), time.Second)
+}
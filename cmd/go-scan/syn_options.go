@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	synscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/syn_scanner"
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+func synOptions(params tcpscanner.Params) (synscanner.Options, error) {
+	opts := synscanner.Options{
+		ScanType:    params.ScanType,
+		Interface:   params.Interface,
+		Retries:     params.Timing.Retries,
+		HostTimeout: params.Timing.HostTimeout,
+	}
+
+	if params.SourceIP != "" {
+		src := net.ParseIP(params.SourceIP)
+		if src == nil {
+			return opts, fmt.Errorf("'%s' is an invalid source IP", params.SourceIP)
+		}
+		if v4 := src.To4(); v4 != nil {
+			src = v4
+		}
+		opts.Source = src
+	}
+
+	return opts, nil
+}
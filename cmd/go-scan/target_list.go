@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	icmpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/icmp_scanner"
+)
+
+// readTargetList reads the -iL file, or stdin if path is "-", into the
+// same form -t takes.
+func readTargetList(path string) (string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open target list: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	spec, err := icmpscanner.ReadTargets(r)
+	if err != nil {
+		return "", err
+	}
+	if spec == "" {
+		return "", fmt.Errorf("no targets found in '%s'", path)
+	}
+	return spec, nil
+}
@@ -0,0 +1,219 @@
+// Package checkpoint saves a port scan's progress to disk so an interrupted
+// scan can pick up where it left off
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+// Version is bumped whenever the file format changes.
+const Version = 1
+
+// Result is one finished host/port pair.
+type Result struct {
+	Host     string               `json:"host"`
+	Port     int                  `json:"port"`
+	State    tcpscanner.PortState `json:"state"`
+	OS       string               `json:"os,omitempty"`
+	Attempts int                  `json:"attempts,omitempty"`
+}
+
+// Checkpoint is a scan's plan, every port of every host, and the results
+// gathered for it so far. The plan runs port by port, taking each host in
+// turn, and Position counts how many of its pairs are finished before the
+// first one that isn't.
+type Checkpoint struct {
+	Version  int      `json:"version"`
+	Args     []string `json:"args"`
+	Hosts    []string `json:"hosts"`
+	Ports    []int    `json:"ports"`
+	Position int      `json:"position"`
+	Results  []Result `json:"results"`
+	// Names maps hosts to the hostnames they were resolved from.
+	Names map[string]string `json:"names,omitempty"`
+
+	path      string
+	hostIndex map[string]int
+	portIndex map[int]int
+	done      []bool
+}
+
+// New returns an empty checkpoint for scanning ports on hosts, saved to path.
+// args are the command-line arguments that started the scan. A checkpoint
+// without a path is kept in memory only.
+func New(path string, args, hosts []string, ports []int) *Checkpoint {
+	c := &Checkpoint{
+		Version: Version,
+		Args:    args,
+		Hosts:   hosts,
+		Ports:   ports,
+		path:    path,
+	}
+	c.index()
+	return c
+}
+
+// PortScanResults turns the result back into the scanner's form.
+func (r Result) PortScanResults() tcpscanner.PortScanResults {
+	return tcpscanner.PortScanResults{
+		TargetIP: net.ParseIP(r.Host),
+		Port:     r.Port,
+		State:    r.State,
+		OS:       r.OS,
+		Attempts: r.Attempts,
+	}
+}
+
+// Load reads a checkpoint saved by Save.
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint json: %w", err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("checkpoint version %d is not supported, want %d", c.Version, Version)
+	}
+
+	c.path = path
+	c.index()
+	for _, res := range c.Results {
+		i, ok := c.plan(res.Host, res.Port)
+		if !ok {
+			return nil, fmt.Errorf("checkpoint has a result for %s:%d, which isn't in its plan", res.Host, res.Port)
+		}
+		c.done[i] = true
+	}
+	c.advance()
+
+	return &c, nil
+}
+
+func (c *Checkpoint) index() {
+	c.hostIndex = make(map[string]int, len(c.Hosts))
+	for i, h := range c.Hosts {
+		c.hostIndex[h] = i
+	}
+	c.portIndex = make(map[int]int, len(c.Ports))
+	for i, p := range c.Ports {
+		c.portIndex[p] = i
+	}
+	c.done = make([]bool, len(c.Hosts)*len(c.Ports))
+}
+
+// plan returns the position of host and port in the plan.
+func (c *Checkpoint) plan(host string, port int) (int, bool) {
+	h, ok := c.hostIndex[host]
+	if !ok {
+		return 0, false
+	}
+	p, ok := c.portIndex[port]
+	if !ok {
+		return 0, false
+	}
+	return p*len(c.Hosts) + h, true
+}
+
+func (c *Checkpoint) advance() {
+	for c.Position < len(c.done) && c.done[c.Position] {
+		c.Position++
+	}
+}
+
+// Path is where the checkpoint is saved.
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// Record adds a finished result. Abandoned ports weren't scanned, so they
+// aren't recorded and a resumed scan probes them again.
+func (c *Checkpoint) Record(res tcpscanner.PortScanResults) {
+	if res.State == tcpscanner.Abandoned {
+		return
+	}
+	host := res.TargetIP.String()
+	i, ok := c.plan(host, res.Port)
+	if !ok || c.done[i] {
+		return
+	}
+
+	c.done[i] = true
+	c.Results = append(c.Results, Result{
+		Host:     host,
+		Port:     res.Port,
+		State:    res.State,
+		OS:       res.OS,
+		Attempts: res.Attempts,
+	})
+	c.advance()
+}
+
+// Skip returns a function reporting whether a host/port pair was already
+// finished when Skip was called. Unlike the checkpoint itself, it is safe
+// to use while more results are being recorded.
+func (c *Checkpoint) Skip() func(host net.IP, port int) bool {
+	done := make([]bool, len(c.done))
+	copy(done, c.done)
+	return func(host net.IP, port int) bool {
+		i, ok := c.plan(host.String(), port)
+		return ok && done[i]
+	}
+}
+
+// Remaining is how many pairs of the plan are still to be scanned.
+func (c *Checkpoint) Remaining() int {
+	return len(c.done) - len(c.Results)
+}
+
+// Save writes the checkpoint to its path, replacing the previous save only
+// once the new one is complete.
+func (c *Checkpoint) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint json: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint file: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the saved checkpoint once the scan no longer needs it.
+func (c *Checkpoint) Remove() error {
+	if c.path == "" {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint file: %w", err)
+	}
+	return nil
+}
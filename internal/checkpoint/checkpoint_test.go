@@ -0,0 +1,59 @@
+package checkpoint
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.json")
+	args := []string{"-t", "10.0.0.1-2", "-p", "22,80"}
+	hosts := []string{"10.0.0.1", "10.0.0.2"}
+	c := New(path, args, hosts, []int{22, 80})
+
+	result := func(host string, port int, state tcpscanner.PortState) tcpscanner.PortScanResults {
+		return tcpscanner.PortScanResults{TargetIP: net.ParseIP(host).To4(), Port: port, State: state}
+	}
+
+	// Test: position only moves past pairs finished in plan order
+	c.Record(result("10.0.0.2", 22, tcpscanner.Closed))
+	assert.Equal(t, 0, c.Position)
+	c.Record(result("10.0.0.1", 22, tcpscanner.Open))
+	assert.Equal(t, 2, c.Position)
+
+	// Test: abandoned ports aren't finished
+	c.Record(result("10.0.0.1", 80, tcpscanner.Abandoned))
+	assert.Equal(t, 2, c.Position)
+	assert.Equal(t, 2, c.Remaining())
+
+	require.NoError(t, c.Save())
+
+	// Test: a loaded checkpoint keeps its plan and skips finished pairs
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, args, loaded.Args)
+	assert.Equal(t, 2, loaded.Position)
+	assert.Len(t, loaded.Results, 2)
+
+	skip := loaded.Skip()
+	assert.True(t, skip(net.ParseIP("10.0.0.1"), 22))
+	assert.True(t, skip(net.ParseIP("10.0.0.2"), 22))
+	assert.False(t, skip(net.ParseIP("10.0.0.1"), 80))
+	assert.False(t, skip(net.ParseIP("10.0.0.3"), 22))
+
+	// Test: Skip doesn't see results recorded after it was taken
+	loaded.Record(result("10.0.0.1", 80, tcpscanner.Closed))
+	assert.False(t, skip(net.ParseIP("10.0.0.1"), 80))
+	assert.Equal(t, 3, loaded.Position)
+
+	// Test: Remove deletes the file
+	require.NoError(t, loaded.Remove())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,106 @@
+// Package config provides GoScan's named timing templates
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timing is every knob that trades scan speed against stealth and
+// accuracy.
+type Timing struct {
+	Name             string
+	DialTimeout      time.Duration
+	PingTimeout      time.Duration
+	HostTimeout      time.Duration
+	Retries          int
+	Workers          int
+	DiscoveryWorkers int
+	ScanDelay        time.Duration
+	MinRate          float64
+	MaxRate          float64
+
+	// ConnectRetries is how often connect scans retry a timed out dial.
+	// Templates leave it at 0: each retry waits out another dial timeout,
+	// so connect scans only retry when -max-retries asks them to.
+	ConnectRetries int
+}
+
+// DefaultTemplate is the template used when none is chosen.
+const DefaultTemplate = 3
+
+// Templates are the -T0 to -T5 timing profiles, from slowest to fastest.
+// -T3 matches GoScan's behaviour before templates existed.
+var Templates = [...]Timing{
+	{
+		Name:             "paranoid",
+		DialTimeout:      10 * time.Second,
+		PingTimeout:      10 * time.Second,
+		Retries:          3,
+		Workers:          1,
+		DiscoveryWorkers: 1,
+		ScanDelay:        5 * time.Minute,
+	},
+	{
+		Name:             "sneaky",
+		DialTimeout:      10 * time.Second,
+		PingTimeout:      10 * time.Second,
+		Retries:          3,
+		Workers:          1,
+		DiscoveryWorkers: 1,
+		ScanDelay:        15 * time.Second,
+	},
+	{
+		Name:             "polite",
+		DialTimeout:      5 * time.Second,
+		PingTimeout:      5 * time.Second,
+		Retries:          3,
+		Workers:          10,
+		DiscoveryWorkers: 10,
+		ScanDelay:        400 * time.Millisecond,
+	},
+	{
+		Name:             "normal",
+		DialTimeout:      2 * time.Second,
+		PingTimeout:      1 * time.Second,
+		Retries:          1,
+		Workers:          100,
+		DiscoveryWorkers: 100,
+	},
+	{
+		Name:             "aggressive",
+		DialTimeout:      1250 * time.Millisecond,
+		PingTimeout:      500 * time.Millisecond,
+		Retries:          1,
+		Workers:          500,
+		DiscoveryWorkers: 200,
+	},
+	{
+		Name:             "insane",
+		DialTimeout:      500 * time.Millisecond,
+		PingTimeout:      300 * time.Millisecond,
+		Retries:          0,
+		Workers:          1000,
+		DiscoveryWorkers: 500,
+	},
+}
+
+func Template(level int) (Timing, error) {
+	if level < 0 || level >= len(Templates) {
+		return Timing{}, fmt.Errorf("timing template must be between 0 and %d, got %d", len(Templates)-1, level)
+	}
+	return Templates[level], nil
+}
+
+// EffectiveMaxRate returns the fastest probes per second the timing allows,
+// taking the scan delay into account, or 0 if it sets no cap.
+func (t Timing) EffectiveMaxRate() float64 {
+	rate := t.MaxRate
+	if t.ScanDelay > 0 {
+		delayRate := 1 / t.ScanDelay.Seconds()
+		if rate == 0 || delayRate < rate {
+			rate = delayRate
+		}
+	}
+	return rate
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	// Test: templates are looked up by level
+	normal, err := Template(DefaultTemplate)
+	require.NoError(t, err)
+	assert.Equal(t, "normal", normal.Name)
+	assert.Equal(t, 2*time.Second, normal.DialTimeout)
+
+	// Test: levels outside -T0 to -T5 are rejected
+	_, err = Template(6)
+	assert.Error(t, err)
+	_, err = Template(-1)
+	assert.Error(t, err)
+}
+
+func TestEffectiveMaxRate(t *testing.T) {
+	// Test: no delay and no max means no cap
+	assert.Equal(t, 0.0, Timing{}.EffectiveMaxRate())
+
+	// Test: a scan delay caps the rate
+	assert.Equal(t, 2.5, Timing{ScanDelay: 400 * time.Millisecond}.EffectiveMaxRate())
+
+	// Test: the lower of the two caps wins
+	assert.Equal(t, 1.0, Timing{ScanDelay: 500 * time.Millisecond, MaxRate: 1}.EffectiveMaxRate())
+	assert.Equal(t, 2.0, Timing{ScanDelay: 100 * time.Millisecond, MaxRate: 2}.EffectiveMaxRate())
+}
@@ -0,0 +1,33 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func scanCheckpointDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(base, "go-scan", "checkpoints"), nil
+}
+
+// CheckpointPath returns a new file under the checkpoints directory for a
+// scan started at started, creating the directory if needed.
+func CheckpointPath(started time.Time) (string, error) {
+	dir, err := scanCheckpointDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%d.json", started.Format("20060102-150405"), os.Getpid())
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,166 @@
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	MagicNanos = 0xa1b23c4d
+
+	LinkTypeEthernet = 1
+	LinkTypeLinuxSLL = 113
+
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+)
+
+// Packet is one captured packet, starting at its IP header.
+type Packet struct {
+	Timestamp time.Time
+	Data      []byte
+}
+
+// Reader reads packets from a pcap file in either byte order and with
+// micro- or nanosecond timestamps. Raw IP, Ethernet and Linux cooked
+// captures are supported; link-layer headers are stripped and non-IP
+// frames skipped.
+type Reader struct {
+	r        *bufio.Reader
+	closer   io.Closer
+	order    binary.ByteOrder
+	nanos    bool
+	LinkType uint32
+}
+
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pcap file: %w", err)
+	}
+
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+
+	return r, nil
+}
+
+func NewReader(rd io.Reader) (*Reader, error) {
+	r := &Reader{r: bufio.NewReader(rd)}
+
+	hdr := make([]byte, 24)
+	if _, err := io.ReadFull(r.r, hdr); err != nil {
+		return nil, fmt.Errorf("failed to read pcap header: %w", err)
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(hdr[0:4]) == MagicMicros:
+		r.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr[0:4]) == MagicMicros:
+		r.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(hdr[0:4]) == MagicNanos:
+		r.order, r.nanos = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(hdr[0:4]) == MagicNanos:
+		r.order, r.nanos = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("not a pcap file")
+	}
+
+	r.LinkType = r.order.Uint32(hdr[20:24]) & 0x0FFFFFFF
+	switch r.LinkType {
+	case LinkTypeRaw, LinkTypeEthernet, LinkTypeLinuxSLL:
+	default:
+		return nil, fmt.Errorf("unsupported pcap link type %d", r.LinkType)
+	}
+
+	return r, nil
+}
+
+// Next returns the next IP packet in the capture, or io.EOF once the
+// capture is exhausted.
+func (r *Reader) Next() (Packet, error) {
+	for {
+		hdr := make([]byte, 16)
+		if _, err := io.ReadFull(r.r, hdr); err != nil {
+			if errors.Is(err, io.EOF) {
+				return Packet{}, io.EOF
+			}
+			return Packet{}, fmt.Errorf("failed to read pcap record: %w", err)
+		}
+
+		sec := int64(r.order.Uint32(hdr[0:4]))
+		frac := int64(r.order.Uint32(hdr[4:8]))
+		if !r.nanos {
+			frac *= 1000
+		}
+		size := r.order.Uint32(hdr[8:12])
+		if size > SnapLen*4 {
+			return Packet{}, fmt.Errorf("pcap record of %d bytes is too large", size)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			return Packet{}, fmt.Errorf("failed to read pcap record: %w", err)
+		}
+
+		ip, ok := r.stripLinkLayer(data)
+		if !ok {
+			continue
+		}
+
+		return Packet{Timestamp: time.Unix(sec, frac), Data: ip}, nil
+	}
+}
+
+func (r *Reader) stripLinkLayer(data []byte) ([]byte, bool) {
+	switch r.LinkType {
+	case LinkTypeEthernet:
+		if len(data) < 14 {
+			return nil, false
+		}
+		etherType := binary.BigEndian.Uint16(data[12:14])
+		data = data[14:]
+		if etherType == etherTypeVLAN {
+			if len(data) < 4 {
+				return nil, false
+			}
+			etherType = binary.BigEndian.Uint16(data[2:4])
+			data = data[4:]
+		}
+		if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+			return nil, false
+		}
+		return data, true
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, false
+		}
+		etherType := binary.BigEndian.Uint16(data[14:16])
+		if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+			return nil, false
+		}
+		return data[16:], true
+	default:
+		if len(data) == 0 {
+			return nil, false
+		}
+		return data, true
+	}
+}
+
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
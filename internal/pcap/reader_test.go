@@ -0,0 +1,79 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+
+	first := time.Unix(1700000000, 123456000)
+	second := first.Add(1500 * time.Microsecond)
+	require.NoError(t, w.WritePacket(first, []byte{0x45, 1, 2, 3}))
+	require.NoError(t, w.WritePacket(second, []byte{0x60, 4, 5, 6}))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(LinkTypeRaw), r.LinkType)
+
+	p, err := r.Next()
+	require.NoError(t, err)
+	assert.True(t, first.Equal(p.Timestamp))
+	assert.Equal(t, []byte{0x45, 1, 2, 3}, p.Data)
+
+	p, err = r.Next()
+	require.NoError(t, err)
+	assert.True(t, second.Equal(p.Timestamp))
+	assert.Equal(t, []byte{0x60, 4, 5, 6}, p.Data)
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReaderEthernet(t *testing.T) {
+	var buf bytes.Buffer
+	hdr := make([]byte, 24)
+	binary.BigEndian.PutUint32(hdr[0:4], MagicNanos)
+	binary.BigEndian.PutUint32(hdr[20:24], LinkTypeEthernet)
+	buf.Write(hdr)
+
+	frame := func(etherType uint16, payload ...byte) {
+		rec := make([]byte, 16)
+		data := make([]byte, 14)
+		binary.BigEndian.PutUint16(data[12:14], etherType)
+		data = append(data, payload...)
+		binary.BigEndian.PutUint32(rec[0:4], 10)
+		binary.BigEndian.PutUint32(rec[4:8], 42)
+		binary.BigEndian.PutUint32(rec[8:12], uint32(len(data)))
+		binary.BigEndian.PutUint32(rec[12:16], uint32(len(data)))
+		buf.Write(rec)
+		buf.Write(data)
+	}
+	frame(0x0806, 0, 1) // ARP is skipped
+	frame(etherTypeIPv4, 0x45, 9)
+
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+
+	p, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x45, 9}, p.Data)
+	assert.Equal(t, time.Unix(10, 42), p.Timestamp)
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// Test: not a pcap file
+	_, err = NewReader(bytes.NewReader(make([]byte, 24)))
+	require.Error(t, err)
+}
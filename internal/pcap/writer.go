@@ -0,0 +1,111 @@
+// Package pcap reads and writes libpcap capture files of raw IP packets
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	MagicMicros  = 0xa1b2c3d4
+	VersionMajor = 2
+	VersionMinor = 4
+	SnapLen      = 65535
+
+	// LinkTypeRaw marks packets that begin with an IPv4 or IPv6 header.
+	LinkTypeRaw = 101
+)
+
+// Writer appends packets to a pcap file. It is safe for concurrent use. The
+// first write error is kept and returned by every later call and by Close.
+type Writer struct {
+	mu     sync.Mutex
+	buf    *bufio.Writer
+	closer io.Closer
+	err    error
+}
+
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pcap file: %w", err)
+	}
+
+	w, err := NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.closer = f
+
+	return w, nil
+}
+
+func NewWriter(w io.Writer) (*Writer, error) {
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:4], MagicMicros)
+	binary.LittleEndian.PutUint16(hdr[4:6], VersionMajor)
+	binary.LittleEndian.PutUint16(hdr[6:8], VersionMinor)
+	binary.LittleEndian.PutUint32(hdr[16:20], SnapLen)
+	binary.LittleEndian.PutUint32(hdr[20:24], LinkTypeRaw)
+
+	buf := bufio.NewWriter(w)
+	if _, err := buf.Write(hdr); err != nil {
+		return nil, fmt.Errorf("failed to write pcap header: %w", err)
+	}
+
+	return &Writer{buf: buf}, nil
+}
+
+func (w *Writer) WritePacket(ts time.Time, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	captured := data
+	if len(captured) > SnapLen {
+		captured = captured[:SnapLen]
+	}
+
+	hdr := make([]byte, 16)
+	binary.LittleEndian.PutUint32(hdr[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:12], uint32(len(captured)))
+	binary.LittleEndian.PutUint32(hdr[12:16], uint32(len(data)))
+
+	if _, err := w.buf.Write(hdr); err != nil {
+		w.err = fmt.Errorf("failed to write pcap record: %w", err)
+		return w.err
+	}
+	if _, err := w.buf.Write(captured); err != nil {
+		w.err = fmt.Errorf("failed to write pcap record: %w", err)
+		return w.err
+	}
+
+	return nil
+}
+
+// Close flushes buffered packets and closes the file opened by Create.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.buf.Flush(); err != nil && w.err == nil {
+		w.err = fmt.Errorf("failed to flush pcap file: %w", err)
+	}
+	if w.closer != nil {
+		if err := w.closer.Close(); err != nil && w.err == nil {
+			w.err = fmt.Errorf("failed to close pcap file: %w", err)
+		}
+	}
+
+	return w.err
+}
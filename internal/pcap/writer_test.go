@@ -0,0 +1,38 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+
+	ts := time.Unix(1700000000, 250000000)
+	require.NoError(t, w.WritePacket(ts, []byte{0x45, 0x00, 0x00, 0x28}))
+	require.NoError(t, w.Close())
+
+	out := buf.Bytes()
+	require.Len(t, out, 24+16+4)
+
+	// Test: global header
+	assert.Equal(t, uint32(MagicMicros), binary.LittleEndian.Uint32(out[0:4]))
+	assert.Equal(t, uint16(VersionMajor), binary.LittleEndian.Uint16(out[4:6]))
+	assert.Equal(t, uint16(VersionMinor), binary.LittleEndian.Uint16(out[6:8]))
+	assert.Equal(t, uint32(LinkTypeRaw), binary.LittleEndian.Uint32(out[20:24]))
+
+	// Test: record header and data
+	rec := out[24:]
+	assert.Equal(t, uint32(1700000000), binary.LittleEndian.Uint32(rec[0:4]))
+	assert.Equal(t, uint32(250000), binary.LittleEndian.Uint32(rec[4:8]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(rec[8:12]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(rec[12:16]))
+	assert.Equal(t, []byte{0x45, 0x00, 0x00, 0x28}, rec[16:])
+}
@@ -0,0 +1,150 @@
+// Package resolver provides GoScan's forward and reverse DNS lookups
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each lookup when no timeout is given.
+const DefaultTimeout = 5 * time.Second
+
+// Family picks which address families hostnames resolve to.
+type Family int
+
+const (
+	Both Family = iota
+	IPv4
+	IPv6
+)
+
+// ParseFamily reads a family as given on the command line: 4, 6 or both.
+func ParseFamily(s string) (Family, error) {
+	switch s {
+	case "", "both":
+		return Both, nil
+	case "4":
+		return IPv4, nil
+	case "6":
+		return IPv6, nil
+	}
+	return Both, fmt.Errorf("'%s' is not a valid address family, want 4, 6 or both", s)
+}
+
+func (f Family) network() string {
+	switch f {
+	case IPv4:
+		return "ip4"
+	case IPv6:
+		return "ip6"
+	}
+	return "ip"
+}
+
+// Resolver looks hostnames up through the system resolver or a chosen DNS
+// server. A nil Resolver uses the system resolver for both families.
+type Resolver struct {
+	resolver *net.Resolver
+	family   Family
+	timeout  time.Duration
+}
+
+// New returns a resolver that asks server, as host or host:port, or the
+// system resolver if server is empty. Each lookup gives up after timeout,
+// or DefaultTimeout if it is 0.
+func New(server string, family Family, timeout time.Duration) (*Resolver, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &Resolver{resolver: net.DefaultResolver, family: family, timeout: timeout}
+
+	if server != "" {
+		addr, err := serverAddr(server)
+		if err != nil {
+			return nil, err
+		}
+		r.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}
+	}
+
+	return r, nil
+}
+
+// serverAddr adds the DNS port to server if it has none.
+func serverAddr(server string) (string, error) {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server, nil
+	}
+	if net.ParseIP(server) == nil {
+		return "", fmt.Errorf("'%s' is an invalid DNS server, want an IP with an optional port", server)
+	}
+	return net.JoinHostPort(server, "53"), nil
+}
+
+// AllFamilies returns a copy of the resolver that looks up both IPv4 and
+// IPv6 addresses.
+func (r *Resolver) AllFamilies() *Resolver {
+	if r == nil {
+		return nil
+	}
+	all := *r
+	all.family = Both
+	return &all
+}
+
+// LookupAddr returns the first name ip's PTR record points to, without the
+// trailing dot.
+func (r *Resolver) LookupAddr(ctx context.Context, ip net.IP) (string, error) {
+	resolver, timeout := r.lookup()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	names, err := resolver.LookupAddr(ctx, ip.String())
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no PTR record for %s", ip)
+	}
+	return strings.TrimSuffix(names[0], "."), nil
+}
+
+func (r *Resolver) lookup() (*net.Resolver, time.Duration) {
+	if r == nil {
+		return net.DefaultResolver, DefaultTimeout
+	}
+	return r.resolver, r.timeout
+}
+
+// LookupHost returns host's addresses in the resolver's families, IPv4
+// ones as 4 bytes.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]net.IP, error) {
+	resolver, timeout := r.lookup()
+	family := Both
+	if r != nil {
+		family = r.family
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ips, err := resolver.LookupIP(ctx, family.network(), host)
+	if err != nil {
+		return nil, err
+	}
+	for i, ip := range ips {
+		if v4 := ip.To4(); v4 != nil {
+			ips[i] = v4
+		}
+	}
+	return ips, nil
+}
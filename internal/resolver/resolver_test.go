@@ -0,0 +1,88 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/CodeZeroSugar/go-scan/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupHost(t *testing.T) {
+	server := testutil.FakeDNS(t)
+	ctx := context.Background()
+
+	// Test: both families by default
+	r, err := New(server, Both, 0)
+	require.NoError(t, err)
+	ips, err := r.LookupHost(ctx, "db01.internal")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"10.2.3.4", "2001:db8::4"}, ipStrings(ips))
+
+	// Test: IPv4 addresses come back as 4 bytes
+	r, err = New(server, IPv4, 0)
+	require.NoError(t, err)
+	ips, err = r.LookupHost(ctx, "db01.internal")
+	require.NoError(t, err)
+	require.Len(t, ips, 1)
+	assert.Equal(t, net.IP{10, 2, 3, 4}, ips[0])
+
+	// Test: only the chosen family is returned
+	r, err = New(server, IPv6, 0)
+	require.NoError(t, err)
+	ips, err = r.LookupHost(ctx, "db01.internal")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2001:db8::4"}, ipStrings(ips))
+
+	// Test: unknown names fail
+	_, err = r.LookupHost(ctx, "nope.internal")
+	assert.Error(t, err)
+}
+
+func TestLookupAddr(t *testing.T) {
+	r, err := New(testutil.FakeDNS(t), Both, 0)
+	require.NoError(t, err)
+
+	// Test: PTR names come back without the trailing dot
+	name, err := r.LookupAddr(context.Background(), net.IP{10, 2, 3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, "db01.internal", name)
+
+	// Test: addresses without a PTR record fail
+	_, err = r.LookupAddr(context.Background(), net.IP{10, 2, 3, 5})
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	// Test: servers without a port get the DNS port
+	addr, err := serverAddr("10.0.0.53")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.53:53", addr)
+	addr, err = serverAddr("2001:db8::53")
+	require.NoError(t, err)
+	assert.Equal(t, "[2001:db8::53]:53", addr)
+	addr, err = serverAddr("10.0.0.53:5353")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.53:5353", addr)
+
+	// Test: servers must be IPs
+	_, err = New("dns.internal", Both, 0)
+	assert.Error(t, err)
+
+	// Test: families are read from the command line form
+	family, err := ParseFamily("6")
+	require.NoError(t, err)
+	assert.Equal(t, IPv6, family)
+	_, err = ParseFamily("7")
+	assert.Error(t, err)
+}
+
+func ipStrings(ips []net.IP) []string {
+	var out []string
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
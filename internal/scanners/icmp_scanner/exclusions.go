@@ -0,0 +1,69 @@
+package icmpscanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+)
+
+// Exclusions are addresses to leave out of a scan. They are kept as ranges
+// rather than expanded, so excluding a /8 costs no more than excluding one
+// address. A nil Exclusions excludes nothing.
+type Exclusions struct {
+	ranges [][2]net.IP
+}
+
+// ParseExclusions reads input in the same syntax as ResolveTargets.
+// Hostnames are resolved to both IPv4 and IPv6 whatever r's family, so no
+// address of an excluded host slips through.
+func ParseExclusions(ctx context.Context, input string, r *resolver.Resolver) (*Exclusions, error) {
+	e := &Exclusions{}
+
+	for _, entry := range strings.Split(input, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if isHostname(entry) {
+			ips, err := r.AllFamilies().LookupHost(ctx, entry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve excluded host '%s': %w", entry, err)
+			}
+			for _, ip := range ips {
+				e.add(ip, ip)
+			}
+			continue
+		}
+
+		start, end, err := parseTarget(entry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse exclusion '%s': %w", entry, err)
+		}
+		if start == nil {
+			start = end
+		}
+		e.add(start, end)
+	}
+
+	return e, nil
+}
+
+func (e *Exclusions) add(start, end net.IP) {
+	e.ranges = append(e.ranges, [2]net.IP{normalizeIP(start), normalizeIP(end)})
+}
+
+// Contains reports whether ip is excluded.
+func (e *Exclusions) Contains(ip net.IP) bool {
+	if e == nil {
+		return false
+	}
+	ip = normalizeIP(ip)
+	for _, r := range e.ranges {
+		if len(ip) == len(r[0]) && bytes.Compare(ip, r[0]) >= 0 && bytes.Compare(ip, r[1]) <= 0 {
+			return true
+		}
+	}
+	return false
+}
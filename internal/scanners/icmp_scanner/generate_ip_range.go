@@ -6,16 +6,25 @@ import (
 )
 
 func GenerateIPRange(start, end net.IP) ([]net.IP, error) {
-	var current net.IP
-	var ipRange []net.IP
-	current = start
-	for bytes.Compare(current.To4(), end.To4()) <= 0 {
+	start, end, err := checkRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Compare(start, end) > 0 {
+		return nil, nil
+	}
+
+	ipRange := make([]net.IP, 0, rangeSize(start, end).Int64())
+	current := start
+	for {
 		ipRange = append(ipRange, current)
-		result, err := incrementIP(current)
+		if current.Equal(end) {
+			break
+		}
+		current, err = incrementIP(current)
 		if err != nil {
 			return nil, err
 		}
-		current = result
 	}
 
 	return ipRange, nil
@@ -2,6 +2,7 @@
 package icmpscanner
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
@@ -9,11 +10,22 @@ import (
 
 	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
 )
 
-func Ping(ipAddr net.IP) (bool, error) {
-	const timeout = 1 * time.Second
-	c, err := icmp.ListenPacket("udp4", "0.0.0.0")
+// Ping sends one echo request to ipAddr, over ICMPv6 for IPv6 addresses,
+// and waits up to timeout for the reply. It gives up early if ctx is done.
+func Ping(ctx context.Context, ipAddr net.IP, timeout time.Duration) (bool, error) {
+	network, listen := "udp4", "0.0.0.0"
+	var request, reply icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	dst := &net.UDPAddr{IP: ipAddr, Zone: "eth0"}
+	if ipAddr.To4() == nil {
+		network, listen = "udp6", "::"
+		request, reply = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		dst = &net.UDPAddr{IP: ipAddr}
+	}
+
+	c, err := icmp.ListenPacket(network, listen)
 	if err != nil {
 		return false, fmt.Errorf("failed to establish icmp packet connection: %w", err)
 	}
@@ -25,8 +37,13 @@ func Ping(ipAddr net.IP) (bool, error) {
 
 	defer c.Close()
 
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.SetDeadline(time.Now())
+	})
+	defer stop()
+
 	wm := icmp.Message{
-		Type: ipv4.ICMPTypeEcho, Code: 0,
+		Type: request, Code: 0,
 		Body: &icmp.Echo{
 			ID: os.Getpid() & 0xffff, Seq: 1,
 			Data: []byte("HELLO-R-U-THERE"),
@@ -37,7 +54,7 @@ func Ping(ipAddr net.IP) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("failed to marshal message bytes: %w", err)
 	}
-	if _, err := c.WriteTo(wb, &net.UDPAddr{IP: ipAddr, Zone: "eth0"}); err != nil {
+	if _, err := c.WriteTo(wb, dst); err != nil {
 		return false, fmt.Errorf("failed to write bytes for icmp: %w", err)
 	}
 
@@ -46,14 +63,12 @@ func Ping(ipAddr net.IP) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("failed to read bytes returned from icmp: %w", err)
 	}
-	rm, err := icmp.ParseMessage(ipv4.ICMPTypeEcho.Protocol(), rb[:n])
+	rm, err := icmp.ParseMessage(request.Protocol(), rb[:n])
 	if err != nil {
 		return false, fmt.Errorf("failed to parse icmp return message: %w", err)
 	}
-	switch rm.Type {
-	case ipv4.ICMPTypeEchoReply:
-		return true, nil
-	default:
+	if rm.Type != reply {
 		return false, fmt.Errorf("got %+v; want echo reply", rm)
 	}
+	return true, nil
 }
@@ -1,30 +1,68 @@
 package icmpscanner
 
 import (
+	"context"
 	"fmt"
-	"net"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
 )
 
-const DiscoveryWorkers = 100
+const (
+	DiscoveryWorkers = 100
+	PingTimeout      = 1 * time.Second
+)
 
-func DiscoveryScan(input string) ([]net.IP, error) {
-	targets, err := ParseTargets(input)
+// Options tune a discovery scan. Zero values fall back to DiscoveryWorkers
+// and PingTimeout, a nil Limiter doesn't pace and a nil Resolver looks
+// hostnames up with the system resolver. Targets in Exclude are dropped
+// before any are pinged.
+type Options struct {
+	Workers  int
+	Timeout  time.Duration
+	Limiter  *timing.Limiter
+	Resolver *resolver.Resolver
+	Exclude  *Exclusions
+}
+
+// DiscoveryScan pings every target in input, resolving any hostnames, and
+// returns those that answered. If ctx is done first it returns the hosts
+// found so far along with ctx's error.
+func DiscoveryScan(ctx context.Context, input string, opts Options) ([]Target, error) {
+	targets, err := ResolveTargets(ctx, input, opts.Resolver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse input for discovery scan: %w", err)
 	}
+	targets = slices.DeleteFunc(targets, func(t Target) bool {
+		return opts.Exclude.Contains(t.IP)
+	})
 
-	jobs := make(chan net.IP)
-	results := make(chan net.IP)
+	jobs := make(chan Target)
+	results := make(chan Target)
 
 	var wg sync.WaitGroup
 
-	for i := 0; i < DiscoveryWorkers; i++ {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DiscoveryWorkers
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = PingTimeout
+	}
+
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for target := range jobs {
-				ok, err := Ping(target)
+				if err := opts.Limiter.Wait(ctx); err != nil {
+					return
+				}
+				ok, err := Ping(ctx, target.IP, timeout)
 				if err != nil {
 					continue
 				}
@@ -36,10 +74,14 @@ func DiscoveryScan(input string) ([]net.IP, error) {
 	}
 
 	go func() {
+		defer close(jobs)
 		for _, t := range targets {
-			jobs <- t
+			select {
+			case jobs <- t:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(jobs)
 	}()
 
 	go func() {
@@ -47,10 +89,10 @@ func DiscoveryScan(input string) ([]net.IP, error) {
 		close(results)
 	}()
 
-	var hostsUp []net.IP
-	for ip := range results {
-		hostsUp = append(hostsUp, ip)
+	var hostsUp []Target
+	for t := range results {
+		hostsUp = append(hostsUp, t)
 	}
 
-	return hostsUp, nil
+	return hostsUp, ctx.Err()
 }
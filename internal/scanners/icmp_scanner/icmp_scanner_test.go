@@ -55,6 +55,22 @@ func TestGenerateIPRange(t *testing.T) {
 			wantFirst: "10.0.0.0",
 			wantLast:  "10.255.255.255",
 		},
+		{
+			name:      "ipv6 range across a group",
+			startStr:  "2001:db8::fffe",
+			endStr:    "2001:db8::1:1",
+			wantCount: 4,
+			wantFirst: "2001:db8::fffe",
+			wantLast:  "2001:db8::1:1",
+		},
+		{
+			name:      "ipv6 range ending at the last address",
+			startStr:  "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe",
+			endStr:    "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			wantCount: 2,
+			wantFirst: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe",
+			wantLast:  "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+		},
 	}
 
 	for _, tt := range tests {
@@ -88,3 +104,31 @@ func TestGenerateIPRange(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateIPRangeLimits(t *testing.T) {
+	// Test: ranges bigger than MaxRangeSize are refused
+	_, err := GenerateIPRange(net.ParseIP("2001:db8::"), net.ParseIP("2001:db8::1:0:0"))
+	if err == nil {
+		t.Errorf("expected an error for a range over %d addresses", MaxRangeSize)
+	}
+
+	// Test: IPv6 ranges are held to the smaller MaxRangeSize6
+	ips, err := GenerateIPRange(net.ParseIP("2001:db8::"), net.ParseIP("2001:db8::ffff"))
+	if err != nil || len(ips) != MaxRangeSize6 {
+		t.Errorf("expected a /112 to expand to %d addresses, got %d: %v", MaxRangeSize6, len(ips), err)
+	}
+	_, err = GenerateIPRange(net.ParseIP("2001:db8::"), net.ParseIP("2001:db8::1:0"))
+	if err == nil {
+		t.Errorf("expected an error for an IPv6 range over %d addresses", MaxRangeSize6)
+	}
+	ips, err = GenerateIPRange(net.ParseIP("10.0.0.0"), net.ParseIP("10.1.0.0"))
+	if err != nil || len(ips) != MaxRangeSize6+1 {
+		t.Errorf("expected IPv4 ranges over %d addresses to be allowed, got %d: %v", MaxRangeSize6, len(ips), err)
+	}
+
+	// Test: mixed IP versions are refused
+	_, err = GenerateIPRange(net.ParseIP("10.0.0.1"), net.ParseIP("::1"))
+	if err == nil {
+		t.Errorf("expected an error for a range mixing IPv4 and IPv6")
+	}
+}
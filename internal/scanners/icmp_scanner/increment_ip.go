@@ -6,15 +6,15 @@ import (
 )
 
 func incrementIP(current net.IP) (net.IP, error) {
-	currentBytes := current.To4()
-	if len(currentBytes) != 4 {
+	currentBytes := normalizeIP(current)
+	if currentBytes == nil {
 		return nil, fmt.Errorf("invalid IP address, cannot increment")
 	}
 
-	bytesCopy := make([]byte, 4)
+	bytesCopy := make([]byte, len(currentBytes))
 	copy(bytesCopy, currentBytes)
 
-	for i := 3; i >= 0; i-- {
+	for i := len(bytesCopy) - 1; i >= 0; i-- {
 		if bytesCopy[i] < 255 {
 			bytesCopy[i]++
 			return net.IP(bytesCopy), nil
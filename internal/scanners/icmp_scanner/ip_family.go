@@ -0,0 +1,51 @@
+package icmpscanner
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// MaxRangeSize is the most addresses one IPv4 range or CIDR may expand to,
+// a /8. MaxRangeSize6 is the IPv6 limit, a /112: IPv6 networks are so
+// sparsely populated that anything bigger is better found some other way
+// than by pinging every address.
+const (
+	MaxRangeSize  = 1 << 24
+	MaxRangeSize6 = 1 << 16
+)
+
+// normalizeIP returns ip as 4 bytes if it is IPv4 and 16 bytes otherwise,
+// or nil if it isn't an IP at all.
+func normalizeIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip.To16()
+}
+
+// rangeSize returns how many addresses lie from start to end, inclusive.
+// Both must already be normalized to the same family.
+func rangeSize(start, end net.IP) *big.Int {
+	size := new(big.Int).SetBytes(end)
+	size.Sub(size, new(big.Int).SetBytes(start))
+	return size.Add(size, big.NewInt(1))
+}
+
+func checkRange(start, end net.IP) (net.IP, net.IP, error) {
+	s, e := normalizeIP(start), normalizeIP(end)
+	if s == nil || e == nil {
+		return nil, nil, fmt.Errorf("invalid IP address in range")
+	}
+	if len(s) != len(e) {
+		return nil, nil, fmt.Errorf("start and end IPs are not the same version")
+	}
+	limit := int64(MaxRangeSize)
+	if len(s) == net.IPv6len {
+		limit = MaxRangeSize6
+	}
+	if size := rangeSize(s, e); size.Cmp(big.NewInt(limit)) > 0 {
+		return nil, nil, fmt.Errorf("range has %s addresses, more than the %d allowed", size, limit)
+	}
+	return s, e, nil
+}
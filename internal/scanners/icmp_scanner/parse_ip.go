@@ -34,18 +34,30 @@ func ParseIPRange(input string) (start, end net.IP, err error) {
 		return nil, end, nil
 	}
 
-	if !strings.Contains(splitIP[1], ".") {
-		s := strings.SplitAfter(splitIP[0], ".")
-		right = s[0] + s[1] + s[2] + right
+	// A short end replaces the start's last IPv4 octet or IPv6 group, as in
+	// 192.168.0.10-20 or 2001:db8::10-20.
+	if strings.Contains(left, ":") {
+		if !strings.Contains(right, ":") {
+			right = left[:strings.LastIndex(left, ":")+1] + right
+		}
+	} else if !strings.Contains(right, ".") {
+		i := strings.LastIndex(left, ".")
+		if i < 0 {
+			return nil, nil, fmt.Errorf("'%s' is an invalid IP", left)
+		}
+		right = left[:i+1] + right
 	}
 
 	start = net.ParseIP(left)
 	if start == nil {
-		return nil, nil, fmt.Errorf("'%s' is an invalid IP", start)
+		return nil, nil, fmt.Errorf("'%s' is an invalid IP", left)
 	}
 	end = net.ParseIP(right)
 	if end == nil {
-		return nil, nil, fmt.Errorf("'%s' is an invalid IP", end)
+		return nil, nil, fmt.Errorf("'%s' is an invalid IP", right)
+	}
+	if (start.To4() == nil) != (end.To4() == nil) {
+		return nil, nil, fmt.Errorf("'%s' and '%s' are not the same IP version", left, right)
 	}
 	if bytes.Compare(start, end) > 0 {
 		return nil, nil, fmt.Errorf("start IP is after end IP")
@@ -1,9 +1,13 @@
 package icmpscanner
 
 import (
+	"context"
 	"net"
+	"strings"
 	"testing"
 
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+	"github.com/CodeZeroSugar/go-scan/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -125,4 +129,129 @@ func TestParseRange(t *testing.T) {
 	assert.Equal(t, "192.168.0.10", scanRange[0].String())
 	assert.Equal(t, "192.168.2.52", scanRange[len(scanRange)-1].String())
 	assert.Equal(t, 279, len(scanRange))
+
+	// Test: IPv6 range, two full addresses
+	ip = "2001:db8::10-2001:db8::20"
+	start, end, err = ParseIPRange(ip)
+	require.NoError(t, err)
+	assert.Equal(t, net.ParseIP("2001:db8::10"), start)
+	assert.Equal(t, net.ParseIP("2001:db8::20"), end)
+
+	// Test: IPv6 range, one full other last group
+	ip = "2001:db8::10-1f"
+	start, end, err = ParseIPRange(ip)
+	require.NoError(t, err)
+	assert.Equal(t, net.ParseIP("2001:db8::10"), start)
+	assert.Equal(t, net.ParseIP("2001:db8::1f"), end)
+
+	// Test: Invalid range mixing IP versions
+	ip = "192.168.0.1-2001:db8::1"
+	_, _, err = ParseIPRange(ip)
+	require.Error(t, err)
+
+	// Test: Full test - IPv6 single, range and CIDR
+	ip = "::1, 2001:db8::1-3, 2001:db8:1::/126"
+	scanRange, err = ParseTargets(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "::1", scanRange[0].String())
+	assert.Equal(t, "2001:db8:1::3", scanRange[len(scanRange)-1].String())
+	assert.Equal(t, 8, len(scanRange))
+
+	// Test: Full test - IPv6 CIDR too big to enumerate
+	ip = "2001:db8::/64"
+	_, err = ParseTargets(ip)
+	require.Error(t, err)
+}
+
+func TestResolveTargets(t *testing.T) {
+	r, err := resolver.New(testutil.FakeDNS(t), resolver.IPv4, 0)
+	require.NoError(t, err)
+
+	// Test: hostnames resolve and keep their name, addresses don't
+	targets, err := ResolveTargets(context.Background(), "db01.internal, 10.0.0.1-2", r)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(targets))
+	assert.Equal(t, "db01.internal (10.2.3.4)", targets[0].String())
+	assert.Equal(t, "10.0.0.1", targets[1].String())
+
+	// Test: an address named by several entries is listed once, with its name
+	targets, err = ResolveTargets(context.Background(), "10.2.3.4, db01.internal", r)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets))
+	assert.Equal(t, "db01.internal", targets[0].Hostname)
+
+	// Test: overlapping ranges list each address once, and a name for an
+	// address in a range is kept
+	targets, err = ResolveTargets(context.Background(), "10.2.3.0/30, 10.2.3.2-5, db01.internal, 10.2.3.1", r)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(targets))
+	assert.Equal(t, "10.2.3.0", targets[0].String())
+	assert.Equal(t, "db01.internal (10.2.3.4)", targets[4].String())
+	assert.Equal(t, "10.2.3.5", targets[5].String())
+
+	// Test: names that don't resolve are rejected
+	_, err = ResolveTargets(context.Background(), "nope.internal", r)
+	assert.Error(t, err)
+
+	// Test: ranges and IPv6 aren't mistaken for hostnames
+	assert.False(t, isHostname("192.168.0.1-20"))
+	assert.False(t, isHostname("fe80::ab"))
+	assert.False(t, isHostname("10.0.0.0/8"))
+	assert.True(t, isHostname("db-01.internal"))
+}
+
+func TestReverseLookup(t *testing.T) {
+	r, err := resolver.New(testutil.FakeDNS(t), resolver.Both, 0)
+	require.NoError(t, err)
+
+	targets := []Target{
+		{IP: net.IP{10, 2, 3, 4}},
+		{IP: net.IP{10, 2, 3, 4}, Hostname: "web01.internal"},
+		{IP: net.IP{10, 2, 3, 5}},
+	}
+	ReverseLookup(context.Background(), targets, r)
+
+	// Test: unnamed targets take their PTR name, named ones keep theirs
+	assert.Equal(t, "db01.internal", targets[0].Hostname)
+	assert.Equal(t, "web01.internal", targets[1].Hostname)
+
+	// Test: targets without a PTR record stay unnamed
+	assert.Equal(t, "", targets[2].Hostname)
+}
+
+func TestExclusions(t *testing.T) {
+	r, err := resolver.New(testutil.FakeDNS(t), resolver.IPv4, 0)
+	require.NoError(t, err)
+
+	exclude, err := ParseExclusions(context.Background(), "172.16.0.0/12, 192.168.0.10-20, 2001:db8:1::/64, db01.internal", r)
+	require.NoError(t, err)
+
+	// Test: CIDRs, ranges and hostnames are excluded without being expanded
+	assert.True(t, exclude.Contains(net.ParseIP("172.20.0.1")))
+	assert.True(t, exclude.Contains(net.ParseIP("192.168.0.15")))
+	assert.True(t, exclude.Contains(net.ParseIP("2001:db8:1::ffff")))
+	assert.False(t, exclude.Contains(net.ParseIP("192.168.0.21")))
+	assert.False(t, exclude.Contains(net.ParseIP("2001:db8:2::1")))
+
+	// Test: hostnames exclude every address they resolve to, in both
+	// families whatever the scan resolves targets to
+	assert.True(t, exclude.Contains(net.ParseIP("10.2.3.4")))
+	assert.True(t, exclude.Contains(net.ParseIP("2001:db8::4")))
+	assert.False(t, exclude.Contains(net.ParseIP("10.2.3.5")))
+
+	// Test: nil excludes nothing
+	var none *Exclusions
+	assert.False(t, none.Contains(net.ParseIP("10.0.0.1")))
+
+	// Test: invalid exclusions are rejected
+	_, err = ParseExclusions(context.Background(), "10.0.0.300", r)
+	assert.Error(t, err)
+}
+
+func TestReadTargets(t *testing.T) {
+	// Test: blank lines and comments are skipped, lines are joined by commas
+	input := "# inventory export\n10.0.0.1\n\n10.0.1.0/24, db01.internal # primary\n  \n"
+	spec, err := ReadTargets(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1,10.0.1.0/24, db01.internal", spec)
 }
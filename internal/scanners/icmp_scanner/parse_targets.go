@@ -12,26 +12,12 @@ func ParseTargets(input string) ([]net.IP, error) {
 		splitInput[i] = strings.TrimSpace(splitInput[i])
 	}
 
-	var start net.IP
-	var end net.IP
 	var scanIPs []net.IP
 
 	for _, ipInput := range splitInput {
-		_, ipNet, err := net.ParseCIDR(ipInput)
-		if err == nil {
-			start = ipNet.IP
-			end = make(net.IP, len(ipNet.IP))
-			copy(end, ipNet.IP)
-
-			for i := range end {
-				end[i] |= ^ipNet.Mask[i]
-			}
-
-		} else {
-			start, end, err = ParseIPRange(ipInput)
-			if err != nil {
-				return nil, fmt.Errorf("unable to parse '%s': %w", input, err)
-			}
+		start, end, err := parseTarget(ipInput)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%s': %w", input, err)
 		}
 
 		if start == nil {
@@ -49,3 +35,22 @@ func ParseTargets(input string) ([]net.IP, error) {
 
 	return scanIPs, nil
 }
+
+// parseTarget reads one CIDR, range or address. Like ParseIPRange, it
+// returns a nil start for a single address.
+func parseTarget(ipInput string) (start, end net.IP, err error) {
+	_, ipNet, err := net.ParseCIDR(ipInput)
+	if err != nil {
+		return ParseIPRange(ipInput)
+	}
+
+	start = ipNet.IP
+	end = make(net.IP, len(ipNet.IP))
+	copy(end, ipNet.IP)
+
+	for i := range end {
+		end[i] |= ^ipNet.Mask[i]
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,30 @@
+package icmpscanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadTargets reads target specs from r, one or more comma-separated per
+// line, and joins them into the form ParseTargets takes. Blank lines and
+// anything after a # are ignored.
+func ReadTargets(r io.Reader) (string, error) {
+	var specs []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		specs = append(specs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read targets: %w", err)
+	}
+
+	return strings.Join(specs, ","), nil
+}
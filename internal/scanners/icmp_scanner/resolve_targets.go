@@ -0,0 +1,159 @@
+package icmpscanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+)
+
+// Target is an address to scan and the hostname it was resolved from, if
+// any.
+type Target struct {
+	IP       net.IP
+	Hostname string
+}
+
+func (t Target) String() string {
+	if t.Hostname == "" {
+		return t.IP.String()
+	}
+	return fmt.Sprintf("%s (%s)", t.Hostname, t.IP)
+}
+
+// ResolveTargets parses input like ParseTargets, but also accepts
+// hostnames anywhere in the list and looks them up with r. Each address is
+// listed once, in the order it first appears.
+func ResolveTargets(ctx context.Context, input string, r *resolver.Resolver) ([]Target, error) {
+	var l targetList
+
+	for _, entry := range strings.Split(input, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if isHostname(entry) {
+			ips, err := r.LookupHost(ctx, entry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve '%s': %w", entry, err)
+			}
+			for _, ip := range ips {
+				l.add(ip, entry)
+			}
+			continue
+		}
+
+		start, end, err := parseTarget(entry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%s': %w", entry, err)
+		}
+		if start == nil {
+			l.add(end, "")
+			continue
+		}
+		if err := l.addRange(start, end); err != nil {
+			return nil, fmt.Errorf("failed to generate IP range from '%s' to '%s': %w", start, end, err)
+		}
+	}
+
+	return l.targets, nil
+}
+
+// targetList collects targets without listing an address twice. Ranges
+// can hold millions of addresses, so rather than remembering each one it
+// remembers the ranges and checks new addresses against them.
+type targetList struct {
+	targets []Target
+	single  map[netip.Addr]int
+	ranges  [][2]net.IP
+}
+
+// add lists a single address, or names it if it was listed unnamed.
+func (l *targetList) add(ip net.IP, hostname string) {
+	ip = normalizeIP(ip)
+	key, _ := netip.AddrFromSlice(ip)
+	if i, ok := l.single[key]; ok {
+		if l.targets[i].Hostname == "" {
+			l.targets[i].Hostname = hostname
+		}
+		return
+	}
+	if l.inRange(ip) {
+		if hostname != "" {
+			for i := range l.targets {
+				if l.targets[i].IP.Equal(ip) && l.targets[i].Hostname == "" {
+					l.targets[i].Hostname = hostname
+					break
+				}
+			}
+		}
+		return
+	}
+
+	if l.single == nil {
+		l.single = make(map[netip.Addr]int)
+	}
+	l.single[key] = len(l.targets)
+	l.targets = append(l.targets, Target{IP: ip, Hostname: hostname})
+}
+
+// addRange lists the addresses from start to end, skipping those already
+// listed. They share one backing array, which for large ranges is far
+// smaller than an allocation per address.
+func (l *targetList) addRange(start, end net.IP) error {
+	start, end, err := checkRange(start, end)
+	if err != nil {
+		return err
+	}
+	if bytes.Compare(start, end) > 0 {
+		return nil
+	}
+
+	n := int(rangeSize(start, end).Int64())
+	l.targets = slices.Grow(l.targets, n)
+	buf := make([]byte, 0, n*len(start))
+
+	ip := slices.Clone(start)
+	for {
+		key, _ := netip.AddrFromSlice(ip)
+		if _, ok := l.single[key]; !ok && !l.inRange(ip) {
+			buf = append(buf, ip...)
+			l.targets = append(l.targets, Target{IP: buf[len(buf)-len(ip) : len(buf) : len(buf)]})
+		}
+		if bytes.Equal(ip, end) {
+			break
+		}
+		for i := len(ip) - 1; i >= 0; i-- {
+			ip[i]++
+			if ip[i] != 0 {
+				break
+			}
+		}
+	}
+
+	l.ranges = append(l.ranges, [2]net.IP{start, end})
+	return nil
+}
+
+func (l *targetList) inRange(ip net.IP) bool {
+	for _, r := range l.ranges {
+		if len(r[0]) == len(ip) && bytes.Compare(r[0], ip) <= 0 && bytes.Compare(ip, r[1]) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostname reports whether entry is a name rather than an address, range
+// or CIDR. Those only hold letters when they are IPv6, which has colons.
+func isHostname(entry string) bool {
+	if strings.ContainsAny(entry, ":/") {
+		return false
+	}
+	return strings.ContainsFunc(entry, func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	})
+}
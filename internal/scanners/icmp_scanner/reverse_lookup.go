@@ -0,0 +1,48 @@
+package icmpscanner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
+)
+
+const ReverseLookupWorkers = 32
+
+// ReverseLookup names every target that has no hostname yet after its PTR
+// record, looking up to ReverseLookupWorkers of them at once. Targets
+// without a PTR record, or whose lookup fails, stay unnamed.
+func ReverseLookup(ctx context.Context, targets []Target, r *resolver.Resolver) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < ReverseLookupWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				name, err := r.LookupAddr(ctx, targets[i].IP)
+				if err != nil {
+					continue
+				}
+				targets[i].Hostname = name
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i, t := range targets {
+			if t.Hostname != "" {
+				continue
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,69 @@
+package synscanner
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"net/netip"
+	"sync"
+)
+
+// Cookies derives the initial sequence number of each probe from a keyed
+// hash of its flow, so replies can be validated without per-probe state.
+// Keyed HMACs are pooled since every probe and reply needs one.
+type Cookies struct {
+	macs sync.Pool
+}
+
+// NewCookies uses a fresh random key, so cookies from one scan are
+// meaningless to every other.
+func NewCookies() *Cookies {
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	return NewCookiesWithKey(key)
+}
+
+// NewCookiesWithKey makes cookies reproducible, e.g. to replay a capture.
+func NewCookiesWithKey(key []byte) *Cookies {
+	c := &Cookies{}
+	c.macs.New = func() any {
+		return hmac.New(sha256.New, key)
+	}
+	return c
+}
+
+func (c *Cookies) Seq(dstIP netip.Addr, dstPort, srcPort uint16) uint32 {
+	var b [20]byte
+	addr := dstIP.As16()
+	copy(b[0:16], addr[:])
+	binary.BigEndian.PutUint16(b[16:18], dstPort)
+	binary.BigEndian.PutUint16(b[18:20], srcPort)
+
+	mac := c.macs.Get().(hash.Hash)
+	defer c.macs.Put(mac)
+	mac.Reset()
+	mac.Write(b[:])
+
+	var sum [sha256.Size]byte
+	return binary.BigEndian.Uint32(mac.Sum(sum[:0]))
+}
+
+// Valid reports whether event answers a probe sent with flags that carried
+// our cookie. Probes with ACK set carry the cookie in their acknowledgement
+// number, which RFC 793 has the RST echo back as its sequence number. All
+// other probes carry it in their sequence number and are acknowledged past
+// the SYN and FIN flags they consumed.
+func (c *Cookies) Valid(event TCPEvent, flags TCPFlags) bool {
+	cookie := c.Seq(event.SrcIP, event.SrcPort, event.DstPort)
+
+	if flags.ACK == 1 {
+		return event.Flags&TCP_RST != 0 && event.Seq == cookie
+	}
+
+	if event.Flags&TCP_ACK == 0 {
+		return false
+	}
+	return event.Ack == cookie+uint32(flags.SYN)+uint32(flags.FIN)
+}
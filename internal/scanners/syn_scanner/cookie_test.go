@@ -0,0 +1,71 @@
+package synscanner
+
+import (
+	"net/netip"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookies(t *testing.T) {
+	c := NewCookies()
+	dst := netip.MustParseAddr("192.168.0.168")
+	seq := c.Seq(dst, 80, 40000)
+	syn := TCPFlags{SYN: 1}
+
+	// Test: SYN/ACK acknowledging our probe
+	event := TCPEvent{SrcIP: dst, SrcPort: 80, DstPort: 40000, Ack: seq + 1, Flags: TCP_SYN | TCP_ACK}
+	assert.True(t, c.Valid(event, syn))
+
+	// Test: RST/ACK acknowledging our probe
+	event.Flags = TCP_RST | TCP_ACK
+	assert.True(t, c.Valid(event, syn))
+
+	// Test: wrong acknowledgement number
+	event.Ack = seq
+	assert.False(t, c.Valid(event, syn))
+
+	// Test: reply from a different port
+	event = TCPEvent{SrcIP: dst, SrcPort: 81, DstPort: 40000, Ack: seq + 1, Flags: TCP_SYN | TCP_ACK}
+	assert.False(t, c.Valid(event, syn))
+
+	// Test: no ACK flag
+	event = TCPEvent{SrcIP: dst, SrcPort: 80, DstPort: 40000, Ack: seq + 1, Flags: TCP_RST}
+	assert.False(t, c.Valid(event, syn))
+
+	// Test: RST answering a FIN probe acknowledges the FIN
+	event = TCPEvent{SrcIP: dst, SrcPort: 80, DstPort: 40000, Ack: seq + 1, Flags: TCP_RST | TCP_ACK}
+	assert.True(t, c.Valid(event, TCPFlags{FIN: 1}))
+
+	// Test: RST answering a NULL probe acknowledges nothing extra
+	assert.False(t, c.Valid(event, TCPFlags{}))
+	event.Ack = seq
+	assert.True(t, c.Valid(event, TCPFlags{}))
+
+	// Test: RST answering an ACK probe echoes the cookie as its sequence number
+	event = TCPEvent{SrcIP: dst, SrcPort: 80, DstPort: 40000, Seq: seq, Flags: TCP_RST}
+	assert.True(t, c.Valid(event, TCPFlags{ACK: 1}))
+	event.Seq = seq + 1
+	assert.False(t, c.Valid(event, TCPFlags{ACK: 1}))
+
+	// Test: different key
+	assert.NotEqual(t, seq, NewCookies().Seq(dst, 80, 40000))
+
+	// Test: the same key gives the same cookies, even to concurrent callers
+	key := []byte("go-scan cookie key")
+	want := NewCookiesWithKey(key).Seq(dst, 80, 40000)
+	shared := NewCookiesWithKey(key)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				assert.Equal(t, want, shared.Seq(dst, 80, 40000))
+				assert.NotEqual(t, want, shared.Seq(dst, 81, 40000))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package synscanner
+
+// Correlator decides which captured packets answer a scan's probes. It is
+// shared by the live Receiver and by Replay so both classify identically.
+type Correlator struct {
+	Port    uint16
+	Cookies *Cookies
+	Flags   TCPFlags
+}
+
+// Accept parses an IPv4 or IPv6 packet and returns the event it carries if
+// it is a reply to one of our probes or an ICMP error quoting one.
+func (c Correlator) Accept(buf []byte) (TCPEvent, bool) {
+	if len(buf) == 0 {
+		return TCPEvent{}, false
+	}
+
+	switch buf[0] >> 4 {
+	case 4:
+		if len(buf) > 9 && buf[9] == protoICMP {
+			event, ok := ParseICMPUnreachable(buf)
+			if !ok {
+				return TCPEvent{}, false
+			}
+			return c.validICMP(event)
+		}
+		return c.validTCP(ParseTCP(buf))
+	case 6:
+		if event, ok := ParseICMPv6Unreachable(buf); ok {
+			return c.validICMP(event)
+		}
+		return c.validTCP(ParseTCP6(buf))
+	default:
+		return TCPEvent{}, false
+	}
+}
+
+func (c Correlator) validTCP(event TCPEvent) (TCPEvent, bool) {
+	if event.DstPort != c.Port || !c.Cookies.Valid(event, c.Flags) {
+		return TCPEvent{}, false
+	}
+	event.Result = event.Classify()
+	return event, true
+}
+
+func (c Correlator) validICMP(event TCPEvent) (TCPEvent, bool) {
+	if event.DstPort != c.Port {
+		return TCPEvent{}, false
+	}
+	if event.Seq != c.Cookies.Seq(event.SrcIP, event.SrcPort, event.DstPort) {
+		return TCPEvent{}, false
+	}
+	return event, true
+}
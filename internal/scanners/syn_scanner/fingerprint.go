@@ -0,0 +1,201 @@
+package synscanner
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+type ipIDClass int
+
+const (
+	ipIDUnknown ipIDClass = iota
+	ipIDZero
+	ipIDIncremental
+	ipIDRandom
+)
+
+// maxIPIDStep is the largest gap between successive IP IDs that still counts
+// as a global incrementing counter rather than a randomized one.
+const maxIPIDStep = 1000
+
+// signature describes the SYN/ACK a family of systems answers a SYN with.
+// Window sizes of zero and an ipID of ipIDUnknown match anything.
+type signature struct {
+	family     string
+	initialTTL uint8
+	df         bool
+	layout     string
+	windows    []uint16
+	ipID       ipIDClass
+}
+
+var signatures = []signature{
+	{family: "Linux", initialTTL: 64, df: true, layout: "M,S,T,N,W", windows: []uint16{65160, 64240, 28960, 14480, 5792}, ipID: ipIDZero},
+	{family: "Linux", initialTTL: 64, df: true, layout: "M,N,N,S,N,W", windows: []uint16{64240, 29200, 14600}, ipID: ipIDZero},
+	{family: "Linux", initialTTL: 64, df: true, layout: "M", windows: []uint16{65495, 64240, 29200}, ipID: ipIDZero},
+	{family: "macOS", initialTTL: 64, df: true, layout: "M,N,W,N,N,T,S", windows: []uint16{65535}},
+	{family: "FreeBSD", initialTTL: 64, df: true, layout: "M,N,W,S,T", windows: []uint16{65535}, ipID: ipIDRandom},
+	{family: "Windows", initialTTL: 128, df: true, layout: "M,N,W,S", windows: []uint16{65535, 64240, 8192}, ipID: ipIDIncremental},
+	{family: "Windows", initialTTL: 128, df: true, layout: "M,N,W,N,N,S", windows: []uint16{65535, 64240, 8192}, ipID: ipIDIncremental},
+	{family: "Windows", initialTTL: 128, df: true, layout: "M,N,W,N,N,T", windows: []uint16{65535, 8192}, ipID: ipIDIncremental},
+	{family: "Solaris", initialTTL: 64, df: true, layout: "N,N,T,M,N,W,N,N,S", windows: []uint16{64000, 49232}},
+	{family: "Cisco IOS", initialTTL: 255, df: false, layout: "M", windows: []uint16{4128}, ipID: ipIDIncremental},
+	{family: "Network device", initialTTL: 255, df: false, layout: "M", windows: []uint16{0}},
+}
+
+// OSGuess is the best matching signature for a host and how many routers
+// its replies crossed.
+type OSGuess struct {
+	Family string
+	Hops   int
+}
+
+func (g OSGuess) String() string {
+	if g.Family == "" {
+		return ""
+	}
+	if g.Hops == 1 {
+		return fmt.Sprintf("%s (1 hop)", g.Family)
+	}
+	return fmt.Sprintf("%s (%d hops)", g.Family, g.Hops)
+}
+
+// Fingerprinter guesses each host's OS from the SYN/ACKs it sends. IP ID
+// behaviour is only visible across replies, so guesses improve as more
+// open ports on a host are found.
+type Fingerprinter struct {
+	mu    sync.Mutex
+	ipIDs map[netip.Addr][]uint16
+}
+
+func NewFingerprinter() *Fingerprinter {
+	return &Fingerprinter{ipIDs: make(map[netip.Addr][]uint16)}
+}
+
+// Observe records a SYN/ACK and returns the current guess for its sender.
+// IPv6 has no IP ID or DF bit, so only TTL, window and options are compared
+// for IPv6 replies.
+func (f *Fingerprinter) Observe(event TCPEvent) OSGuess {
+	if event.SrcIP.Is6() {
+		return matchSignature(event, ipIDUnknown)
+	}
+
+	f.mu.Lock()
+	f.ipIDs[event.SrcIP] = append(f.ipIDs[event.SrcIP], event.IPID)
+	class := classifyIPIDs(f.ipIDs[event.SrcIP])
+	f.mu.Unlock()
+
+	return matchSignature(event, class)
+}
+
+func matchSignature(event TCPEvent, class ipIDClass) OSGuess {
+	initial := initialTTL(event.TTL)
+	layout := optionLayout(event.Options)
+
+	best := -1
+	var guess OSGuess
+	for _, sig := range signatures {
+		if sig.initialTTL != initial {
+			continue
+		}
+
+		// DF, IP ID and TTL are shared by too many systems to tell them
+		// apart, so a signature needs its layout or a window to match.
+		layoutMatch := sig.layout == layout
+		windowMatch := false
+		for _, w := range sig.windows {
+			if w == event.Window {
+				windowMatch = true
+				break
+			}
+		}
+		if !layoutMatch && !windowMatch {
+			continue
+		}
+
+		score := 0
+		if layoutMatch {
+			score += 3
+		}
+		if windowMatch {
+			score += 2
+		}
+		if sig.df == event.DF && event.SrcIP.Is4() {
+			score++
+		}
+		if sig.ipID != ipIDUnknown && sig.ipID == class {
+			score++
+		}
+
+		if score > best {
+			best = score
+			guess = OSGuess{Family: sig.family, Hops: int(initial - event.TTL)}
+		}
+	}
+
+	return guess
+}
+
+// initialTTL rounds an observed TTL up to the nearest common initial value.
+func initialTTL(ttl uint8) uint8 {
+	for _, initial := range []uint8{32, 64, 128} {
+		if ttl <= initial {
+			return initial
+		}
+	}
+	return 255
+}
+
+// optionLayout renders the order of options, e.g. "M,S,T,N,W". EOL is left
+// out since it only marks padding.
+func optionLayout(opts []TCPOption) string {
+	var parts []string
+	for _, o := range opts {
+		switch o.Kind {
+		case OptEOL:
+			continue
+		case OptNOP:
+			parts = append(parts, "N")
+		case OptMSS:
+			parts = append(parts, "M")
+		case OptWindowScale:
+			parts = append(parts, "W")
+		case OptSACKPermitted:
+			parts = append(parts, "S")
+		case OptTimestamps:
+			parts = append(parts, "T")
+		default:
+			parts = append(parts, "?")
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func classifyIPIDs(ids []uint16) ipIDClass {
+	if len(ids) == 0 {
+		return ipIDUnknown
+	}
+
+	zero := true
+	for _, id := range ids {
+		if id != 0 {
+			zero = false
+			break
+		}
+	}
+	if zero {
+		return ipIDZero
+	}
+
+	if len(ids) < 2 {
+		return ipIDUnknown
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i]-ids[i-1] > maxIPIDStep {
+			return ipIDRandom
+		}
+	}
+	return ipIDIncremental
+}
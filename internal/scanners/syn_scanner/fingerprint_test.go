@@ -0,0 +1,43 @@
+package synscanner
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprinter(t *testing.T) {
+	linux := []TCPOption{MSSOption(1460), SACKPermittedOption(), TimestampsOption(1, 1), NOPOption(), WindowScaleOption(7)}
+	windows := []TCPOption{MSSOption(1460), NOPOption(), WindowScaleOption(8), NOPOption(), NOPOption(), SACKPermittedOption()}
+
+	// Test: Linux SYN/ACK two hops away
+	f := NewFingerprinter()
+	event := TCPEvent{SrcIP: netip.AddrFrom4([4]byte{10, 0, 0, 1}), TTL: 62, DF: true, Window: 65160, Options: linux}
+	assert.Equal(t, OSGuess{Family: "Linux", Hops: 2}, f.Observe(event))
+
+	// Test: Windows SYN/ACKs with an incrementing IP ID
+	event = TCPEvent{SrcIP: netip.AddrFrom4([4]byte{10, 0, 0, 2}), TTL: 128, DF: true, Window: 65535, IPID: 100, Options: windows}
+	f.Observe(event)
+	event.IPID = 104
+	guess := f.Observe(event)
+	assert.Equal(t, "Windows", guess.Family)
+	assert.Equal(t, "Windows (0 hops)", guess.String())
+
+	// Test: TTL alone is not enough for a guess
+	event = TCPEvent{SrcIP: netip.AddrFrom4([4]byte{10, 0, 0, 3}), TTL: 50, Window: 1234}
+	assert.Equal(t, OSGuess{}, f.Observe(event))
+	assert.Equal(t, "", f.Observe(event).String())
+
+	// Test: DF and a zero IP ID are not enough without the layout or window
+	event = TCPEvent{SrcIP: netip.AddrFrom4([4]byte{10, 0, 0, 4}), TTL: 64, DF: true, Window: 1234}
+	f.Observe(event)
+	assert.Equal(t, OSGuess{}, f.Observe(event))
+}
+
+func TestClassifyIPIDs(t *testing.T) {
+	assert.Equal(t, ipIDZero, classifyIPIDs([]uint16{0, 0, 0}))
+	assert.Equal(t, ipIDUnknown, classifyIPIDs([]uint16{17}))
+	assert.Equal(t, ipIDIncremental, classifyIPIDs([]uint16{65530, 65534, 3}))
+	assert.Equal(t, ipIDRandom, classifyIPIDs([]uint16{100, 40000, 7}))
+}
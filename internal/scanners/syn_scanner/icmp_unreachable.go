@@ -0,0 +1,70 @@
+package synscanner
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+const (
+	icmpDestUnreachable = 3
+
+	icmpHostUnreachable     = 1
+	icmpProtoUnreachable    = 2
+	icmpPortUnreachable     = 3
+	icmpNetProhibited       = 9
+	icmpHostProhibited      = 10
+	icmpCommProhibited      = 13
+	icmpQuotedTCPHeaderSize = 8
+)
+
+// ParseICMPUnreachable parses an IPv4 packet carrying an ICMP destination
+// unreachable message that quotes one of our TCP probes. The returned event
+// is oriented like a reply from the probed port, and its Seq holds the
+// sequence number of the quoted probe.
+func ParseICMPUnreachable(buf []byte) (TCPEvent, bool) {
+	if len(buf) < 20 || buf[0]>>4 != 4 || buf[9] != protoICMP {
+		return TCPEvent{}, false
+	}
+
+	ihl := int(buf[0]&0x0F) * 4
+	if len(buf) < ihl+8 {
+		return TCPEvent{}, false
+	}
+	icmp := buf[ihl:]
+
+	if icmp[0] != icmpDestUnreachable {
+		return TCPEvent{}, false
+	}
+
+	var result TCPResult
+	switch icmp[1] {
+	case icmpHostUnreachable:
+		result = TCPUnreachable
+	case icmpProtoUnreachable, icmpPortUnreachable, icmpNetProhibited, icmpHostProhibited, icmpCommProhibited:
+		result = TCPFiltered
+	default:
+		return TCPEvent{}, false
+	}
+
+	quoted := icmp[8:]
+	if len(quoted) < 20 || quoted[0]>>4 != 4 || quoted[9] != protoTCP {
+		return TCPEvent{}, false
+	}
+
+	qihl := int(quoted[0]&0x0F) * 4
+	if len(quoted) < qihl+icmpQuotedTCPHeaderSize {
+		return TCPEvent{}, false
+	}
+	tcp := quoted[qihl:]
+
+	event := TCPEvent{
+		SrcIP:   netip.AddrFrom4([4]byte(quoted[16:20])),
+		DstIP:   netip.AddrFrom4([4]byte(quoted[12:16])),
+		SrcPort: binary.BigEndian.Uint16(tcp[2:4]),
+		DstPort: binary.BigEndian.Uint16(tcp[0:2]),
+		Seq:     binary.BigEndian.Uint32(tcp[4:8]),
+		Result:  result,
+	}
+
+	return event, true
+}
@@ -0,0 +1,46 @@
+package synscanner
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func icmpUnreachable(code uint8, quoted []byte) []byte {
+	outer := IPSegment{Version: 4, IHL: 5, TTL: 64, Protocol: 1, TotalLength: uint16(28 + len(quoted))}
+	buf := outer.Marshal()
+	buf = append(buf, icmpDestUnreachable, code, 0, 0, 0, 0, 0, 0)
+	return append(buf, quoted...)
+}
+
+func TestParseICMPUnreachable(t *testing.T) {
+	p, err := NewPacket("192.168.0.10", "192.168.0.168", 443)
+	require.NoError(t, err)
+	p.TCPSeg.SrcPort = 40000
+	p.TCPSeg.SeqNumber = 0xdeadbeef
+	require.NoError(t, p.GeneratePacket())
+
+	// Test: administratively prohibited quoting our probe
+	event, ok := ParseICMPUnreachable(icmpUnreachable(icmpCommProhibited, p.Bytes[:28]))
+	require.True(t, ok)
+	assert.Equal(t, TCPFiltered, event.Result)
+	assert.Equal(t, netip.MustParseAddr("192.168.0.168"), event.SrcIP)
+	assert.Equal(t, uint16(443), event.SrcPort)
+	assert.Equal(t, uint16(40000), event.DstPort)
+	assert.Equal(t, uint32(0xdeadbeef), event.Seq)
+
+	// Test: host unreachable
+	event, ok = ParseICMPUnreachable(icmpUnreachable(icmpHostUnreachable, p.Bytes[:28]))
+	require.True(t, ok)
+	assert.Equal(t, TCPUnreachable, event.Result)
+
+	// Test: network unreachable is ignored
+	_, ok = ParseICMPUnreachable(icmpUnreachable(0, p.Bytes[:28]))
+	assert.False(t, ok)
+
+	// Test: truncated quote
+	_, ok = ParseICMPUnreachable(icmpUnreachable(icmpPortUnreachable, p.Bytes[:24]))
+	assert.False(t, ok)
+}
@@ -0,0 +1,161 @@
+package synscanner
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+const (
+	ipv6HeaderLen = 40
+
+	ipv6HopByHop    = 0
+	ipv6Routing     = 43
+	ipv6Fragment    = 44
+	ipv6AuthHeader  = 51
+	ipv6DestOptions = 60
+
+	icmpv6DestUnreachable = 1
+
+	icmpv6NoRoute         = 0
+	icmpv6AdminProhibited = 1
+	icmpv6BeyondScope     = 2
+	icmpv6AddrUnreachable = 3
+	icmpv6PortUnreachable = 4
+	icmpv6PolicyFailed    = 5
+	icmpv6RejectRoute     = 6
+)
+
+type IPv6Segment struct {
+	TrafficClass  uint8
+	FlowLabel     uint32
+	PayloadLength uint16
+	NextHeader    uint8
+	HopLimit      uint8
+	SrcAddr       [16]byte
+	DstAddr       [16]byte
+}
+
+func (i *IPv6Segment) Marshal() []byte {
+	buf := make([]byte, ipv6HeaderLen)
+
+	binary.BigEndian.PutUint32(buf[0:4], 6<<28|uint32(i.TrafficClass)<<20|i.FlowLabel&0xFFFFF)
+	binary.BigEndian.PutUint16(buf[4:6], i.PayloadLength)
+	buf[6] = i.NextHeader
+	buf[7] = i.HopLimit
+	copy(buf[8:24], i.SrcAddr[:])
+	copy(buf[24:40], i.DstAddr[:])
+
+	return buf
+}
+
+func buildPseudoHeader6(srcIP, dstIP [16]byte, tcpSegment []byte) []byte {
+	psh := make([]byte, 40+len(tcpSegment))
+
+	copy(psh[0:16], srcIP[:])
+	copy(psh[16:32], dstIP[:])
+	binary.BigEndian.PutUint32(psh[32:36], uint32(len(tcpSegment)))
+	psh[39] = protoTCP
+
+	copy(psh[40:], tcpSegment)
+
+	return psh
+}
+
+// upperLayer skips the IPv6 header and any extension headers in buf and
+// returns the upper-layer protocol and its payload. Non-initial fragments
+// carry no upper-layer header and are rejected.
+func upperLayer(buf []byte) (uint8, []byte, bool) {
+	if len(buf) < ipv6HeaderLen || buf[0]>>4 != 6 {
+		return 0, nil, false
+	}
+
+	next := buf[6]
+	payload := buf[ipv6HeaderLen:]
+
+	for {
+		switch next {
+		case ipv6HopByHop, ipv6Routing, ipv6DestOptions:
+			if len(payload) < 8 {
+				return 0, nil, false
+			}
+			size := (int(payload[1]) + 1) * 8
+			if len(payload) < size {
+				return 0, nil, false
+			}
+			next, payload = payload[0], payload[size:]
+		case ipv6AuthHeader:
+			if len(payload) < 8 {
+				return 0, nil, false
+			}
+			size := (int(payload[1]) + 2) * 4
+			if len(payload) < size {
+				return 0, nil, false
+			}
+			next, payload = payload[0], payload[size:]
+		case ipv6Fragment:
+			if len(payload) < 8 || binary.BigEndian.Uint16(payload[2:4])&0xFFF8 != 0 {
+				return 0, nil, false
+			}
+			next, payload = payload[0], payload[8:]
+		default:
+			return next, payload, true
+		}
+	}
+}
+
+// ParseTCP6 parses an IPv6 packet carrying a TCP segment, skipping any
+// extension headers in front of it.
+func ParseTCP6(buf []byte) TCPEvent {
+	proto, tcp, ok := upperLayer(buf)
+	if !ok || proto != protoTCP {
+		return TCPEvent{}
+	}
+
+	event, ok := parseTCPHeader(tcp)
+	if !ok {
+		return TCPEvent{}
+	}
+	event.SrcIP = netip.AddrFrom16([16]byte(buf[8:24]))
+	event.DstIP = netip.AddrFrom16([16]byte(buf[24:40]))
+	event.TTL = buf[7]
+
+	return event
+}
+
+// ParseICMPv6Unreachable is the IPv6 counterpart of ParseICMPUnreachable.
+func ParseICMPv6Unreachable(buf []byte) (TCPEvent, bool) {
+	proto, icmp, ok := upperLayer(buf)
+	if !ok || proto != protoICMPv6 || len(icmp) < 8 {
+		return TCPEvent{}, false
+	}
+	if icmp[0] != icmpv6DestUnreachable {
+		return TCPEvent{}, false
+	}
+
+	var result TCPResult
+	switch icmp[1] {
+	case icmpv6NoRoute, icmpv6AddrUnreachable:
+		result = TCPUnreachable
+	case icmpv6AdminProhibited, icmpv6BeyondScope, icmpv6PortUnreachable, icmpv6PolicyFailed, icmpv6RejectRoute:
+		result = TCPFiltered
+	default:
+		return TCPEvent{}, false
+	}
+
+	quoted := icmp[8:]
+	proto, tcp, ok := upperLayer(quoted)
+	if !ok || proto != protoTCP || len(tcp) < icmpQuotedTCPHeaderSize {
+		return TCPEvent{}, false
+	}
+
+	event := TCPEvent{
+		SrcIP:   netip.AddrFrom16([16]byte(quoted[24:40])),
+		DstIP:   netip.AddrFrom16([16]byte(quoted[8:24])),
+		SrcPort: binary.BigEndian.Uint16(tcp[2:4]),
+		DstPort: binary.BigEndian.Uint16(tcp[0:2]),
+		Seq:     binary.BigEndian.Uint32(tcp[4:8]),
+		Result:  result,
+	}
+
+	return event, true
+}
@@ -0,0 +1,62 @@
+package synscanner
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPv6Packet(t *testing.T) {
+	p, err := NewPacket("fd00::2", "fd00::1", 22)
+	require.NoError(t, err)
+	p.TCPSeg.SrcPort = 40000
+	p.TCPSeg.Options = []TCPOption{MSSOption(1440)}
+	require.NoError(t, p.GeneratePacket())
+	require.True(t, p.IsIPv6())
+	require.Len(t, p.Bytes, 64)
+
+	// Test: checksum over the IPv6 pseudo header verifies to zero
+	psh := buildPseudoHeader6(p.IP6Seg.SrcAddr, p.IP6Seg.DstAddr, p.Bytes[40:])
+	assert.Equal(t, uint16(0), CalcChecksum(psh))
+
+	// Test: plain IPv6 + TCP
+	event := ParseTCP6(p.Bytes)
+	assert.Equal(t, netip.MustParseAddr("fd00::2"), event.SrcIP)
+	assert.Equal(t, uint16(22), event.DstPort)
+	assert.Equal(t, uint8(64), event.TTL)
+	assert.Equal(t, []TCPOption{MSSOption(1440)}, event.Options)
+
+	// Test: hop-by-hop and destination options headers are skipped
+	withExt := append([]byte{}, p.Bytes[:40]...)
+	withExt[6] = ipv6HopByHop
+	withExt = append(withExt, ipv6DestOptions, 0, 1, 4, 0, 0, 0, 0)
+	withExt = append(withExt, protoTCP, 1, 1, 12, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	withExt = append(withExt, p.Bytes[40:]...)
+	event = ParseTCP6(withExt)
+	assert.Equal(t, uint16(22), event.DstPort)
+	assert.Equal(t, uint16(40000), event.SrcPort)
+
+	// Test: non-initial fragments are rejected
+	frag := append([]byte{}, p.Bytes[:40]...)
+	frag[6] = ipv6Fragment
+	frag = append(frag, protoTCP, 0, 0, 8, 0, 0, 0, 1)
+	frag = append(frag, p.Bytes[40:]...)
+	assert.Equal(t, uint16(0), ParseTCP6(frag).DstPort)
+
+	// Test: ICMPv6 administratively prohibited quoting the probe
+	outer := IPv6Segment{NextHeader: protoICMPv6, HopLimit: 64, PayloadLength: uint16(8 + 48)}
+	icmp := append(outer.Marshal(), icmpv6DestUnreachable, icmpv6AdminProhibited, 0, 0, 0, 0, 0, 0)
+	icmp = append(icmp, p.Bytes[:48]...)
+	unreach, ok := ParseICMPv6Unreachable(icmp)
+	require.True(t, ok)
+	assert.Equal(t, TCPFiltered, unreach.Result)
+	assert.Equal(t, netip.MustParseAddr("fd00::1"), unreach.SrcIP)
+	assert.Equal(t, uint16(22), unreach.SrcPort)
+	assert.Equal(t, uint16(40000), unreach.DstPort)
+
+	// Test: mixed address families
+	_, err = NewPacket("192.168.0.10", "fd00::1", 22)
+	require.Error(t, err)
+}
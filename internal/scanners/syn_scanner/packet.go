@@ -4,7 +4,15 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
-	"syscall"
+	"time"
+)
+
+// IP protocol numbers. They are the same on every platform, but the syscall
+// package doesn't define them on all of them.
+const (
+	protoICMP   = 1
+	protoTCP    = 6
+	protoICMPv6 = 58
 )
 
 type TCPFlags struct {
@@ -35,13 +43,22 @@ func packOffsetFlags(offset uint8, flags uint16) uint16 {
 	return uint16(offset&0xF)<<12 | (flags & 0x01FF)
 }
 
+// Packet is a probe with its IP header. IPSeg is used for IPv4
+// destinations and IP6Seg for IPv6 ones.
 type Packet struct {
 	IPSeg       IPSegment
+	IP6Seg      IPv6Segment
 	TCPSeg      TCPSegment
 	Destination net.IP
 	Bytes       []byte
 }
 
+// PacketRecorder is handed a copy of every packet sent or accepted by a
+// scan, such as a pcap.Writer.
+type PacketRecorder interface {
+	WritePacket(ts time.Time, data []byte) error
+}
+
 type IPSegment struct {
 	Version        uint8
 	IHL            uint8
@@ -65,6 +82,7 @@ type TCPSegment struct {
 	Flags      TCPFlags
 	WindowSize uint16
 	UrgPointer uint16
+	Options    []TCPOption
 }
 
 func CalcChecksum(msg []byte) uint16 {
@@ -88,7 +106,7 @@ func buildPseudoHeader(srcIP, dstIP uint32, tcpSegment []byte) []byte {
 	binary.BigEndian.PutUint32(psh[4:8], dstIP)
 
 	psh[8] = 0
-	psh[9] = syscall.IPPROTO_TCP
+	psh[9] = protoTCP
 
 	binary.BigEndian.PutUint16(psh[10:12], uint16(len(tcpSegment)))
 
@@ -121,8 +139,30 @@ func (i *IPSegment) Marshal() []byte {
 	return buf
 }
 
-func (t *TCPSegment) Marshal(srcIP, dstIP uint32) []byte {
-	buf := make([]byte, 20)
+// Marshal encodes the segment with its options and checksum. DataOffset is
+// set from the encoded header length.
+func (t *TCPSegment) Marshal(srcIP, dstIP uint32) ([]byte, error) {
+	return t.marshal(func(seg []byte) []byte {
+		return buildPseudoHeader(srcIP, dstIP, seg)
+	})
+}
+
+// Marshal6 is Marshal with the checksum taken over the IPv6 pseudo-header.
+func (t *TCPSegment) Marshal6(srcIP, dstIP [16]byte) ([]byte, error) {
+	return t.marshal(func(seg []byte) []byte {
+		return buildPseudoHeader6(srcIP, dstIP, seg)
+	})
+}
+
+func (t *TCPSegment) marshal(pseudoHeader func([]byte) []byte) ([]byte, error) {
+	opts, err := MarshalOptions(t.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 20+len(opts))
+	copy(buf[20:], opts)
+	t.DataOffset = uint8(len(buf) / 4)
 
 	binary.BigEndian.PutUint16(buf[0:2], t.SrcPort)
 	binary.BigEndian.PutUint16(buf[2:4], t.DstPort)
@@ -136,11 +176,10 @@ func (t *TCPSegment) Marshal(srcIP, dstIP uint32) []byte {
 	binary.BigEndian.PutUint16(buf[16:18], 0)
 	binary.BigEndian.PutUint16(buf[18:20], t.UrgPointer)
 
-	psh := buildPseudoHeader(srcIP, dstIP, buf)
-	csum := CalcChecksum(psh)
+	csum := CalcChecksum(pseudoHeader(buf))
 	binary.BigEndian.PutUint16(buf[16:18], csum)
 
-	return buf
+	return buf, nil
 }
 
 func (t *TCPSegment) BuildRST(seq uint32, ack uint32) TCPSegment {
@@ -159,46 +198,75 @@ func (t *TCPSegment) BuildRST(seq uint32, ack uint32) TCPSegment {
 	}
 }
 
-func (p *Packet) GeneratePacket() {
-	ipBytes := p.IPSeg.Marshal()
-	tcpBytes := p.TCPSeg.Marshal(p.IPSeg.SrcAddr, p.IPSeg.DstAddr)
-	p.Bytes = append(ipBytes, tcpBytes...)
+func (p *Packet) IsIPv6() bool {
+	return p.Destination.To4() == nil
 }
 
-func (p *Packet) SendPacket() error {
-	s, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, syscall.IPPROTO_TCP)
-	if err != nil {
-		return fmt.Errorf("failed to create socket: %w", err)
-	}
-	defer syscall.Close(s)
-	err = syscall.SetsockoptInt(s, syscall.IPPROTO_IP, syscall.IP_HDRINCL, 1)
-	if err != nil {
-		return fmt.Errorf("failed to set socket opt: %w", err)
-	}
-	var dstAddr [4]byte
-	copy(dstAddr[:], p.Destination.To4())
-
-	to := &syscall.SockaddrInet4{
-		Addr: dstAddr,
+func (p *Packet) GeneratePacket() error {
+	if p.IsIPv6() {
+		tcpBytes, err := p.TCPSeg.Marshal6(p.IP6Seg.SrcAddr, p.IP6Seg.DstAddr)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tcp segment: %w", err)
+		}
+		p.IP6Seg.PayloadLength = uint16(len(tcpBytes))
+		p.Bytes = append(p.IP6Seg.Marshal(), tcpBytes...)
+		return nil
 	}
 
-	err = syscall.Sendto(s, p.Bytes, 0, to)
+	tcpBytes, err := p.TCPSeg.Marshal(p.IPSeg.SrcAddr, p.IPSeg.DstAddr)
 	if err != nil {
-		return fmt.Errorf("failed to send packet over raw socket: %w", err)
+		return fmt.Errorf("failed to marshal tcp segment: %w", err)
 	}
-
+	p.IPSeg.TotalLength = uint16(20 + len(tcpBytes))
+	ipBytes := p.IPSeg.Marshal()
+	p.Bytes = append(ipBytes, tcpBytes...)
 	return nil
 }
 
+// NewPacket builds a SYN probe. Both addresses must be of the same family.
 func NewPacket(srcIP, dstIP string, dstPort uint16) (*Packet, error) {
-	srcAddr := net.ParseIP(srcIP).To4()
+	srcAddr := net.ParseIP(srcIP)
 	if srcAddr == nil {
 		return nil, fmt.Errorf("failed to parse '%s' to address", srcIP)
 	}
-	dstAddr := net.ParseIP(dstIP).To4()
+	dstAddr := net.ParseIP(dstIP)
 	if dstAddr == nil {
-		return nil, fmt.Errorf("failed to parse '%s' to address", srcIP)
+		return nil, fmt.Errorf("failed to parse '%s' to address", dstIP)
 	}
+	if (srcAddr.To4() == nil) != (dstAddr.To4() == nil) {
+		return nil, fmt.Errorf("source '%s' and destination '%s' are different address families", srcIP, dstIP)
+	}
+
+	tcp := TCPSegment{
+		SrcPort:    SourcePort,
+		DstPort:    dstPort,
+		SeqNumber:  0x0,
+		AckNumber:  0x0,
+		DataOffset: 0x5,
+		Flags:      TCPFlags{SYN: 1},
+		WindowSize: 65535,
+		UrgPointer: 0x0,
+	}
+
+	if dstAddr.To4() == nil {
+		ip6 := IPv6Segment{
+			PayloadLength: uint16(20),
+			NextHeader:    protoTCP,
+			HopLimit:      64,
+			SrcAddr:       [16]byte(srcAddr.To16()),
+			DstAddr:       [16]byte(dstAddr.To16()),
+		}
+
+		packet := &Packet{
+			IP6Seg:      ip6,
+			TCPSeg:      tcp,
+			Destination: dstAddr.To16(),
+		}
+
+		return packet, nil
+	}
+
+	srcAddr, dstAddr = srcAddr.To4(), dstAddr.To4()
 
 	ip := IPSegment{
 		Version:        0x4,
@@ -209,22 +277,11 @@ func NewPacket(srcIP, dstIP string, dstPort uint16) (*Packet, error) {
 		Flags:          2,
 		FragmentOffset: 0x0,
 		TTL:            64,
-		Protocol:       syscall.IPPROTO_TCP,
+		Protocol:       protoTCP,
 		SrcAddr:        binary.BigEndian.Uint32(srcAddr),
 		DstAddr:        binary.BigEndian.Uint32(dstAddr),
 	}
 
-	tcp := TCPSegment{
-		SrcPort:    12345,
-		DstPort:    dstPort,
-		SeqNumber:  0x0,
-		AckNumber:  0x0,
-		DataOffset: 0x5,
-		Flags:      TCPFlags{SYN: 1},
-		WindowSize: 65535,
-		UrgPointer: 0x0,
-	}
-
 	packet := &Packet{
 		IPSeg:       ip,
 		TCPSeg:      tcp,
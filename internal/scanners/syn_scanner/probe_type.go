@@ -0,0 +1,78 @@
+package synscanner
+
+import (
+	"fmt"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+// probeFlags returns the TCP flags sent by each raw scan type.
+func probeFlags(scanType tcpscanner.ScanType) (TCPFlags, error) {
+	switch scanType {
+	case tcpscanner.SYN:
+		return TCPFlags{SYN: 1}, nil
+	case tcpscanner.FIN:
+		return TCPFlags{FIN: 1}, nil
+	case tcpscanner.NULL:
+		return TCPFlags{}, nil
+	case tcpscanner.Xmas:
+		return TCPFlags{FIN: 1, PSH: 1, URG: 1}, nil
+	case tcpscanner.ACK, tcpscanner.Window:
+		return TCPFlags{ACK: 1}, nil
+	case tcpscanner.Maimon:
+		return TCPFlags{FIN: 1, ACK: 1}, nil
+	default:
+		return TCPFlags{}, fmt.Errorf("scan type %d does not use raw packets", scanType)
+	}
+}
+
+// silentState is the state of a port whose probe was never answered. RFC 793
+// has closed ports answer any segment without SYN with a RST and open ports
+// drop it, so silence only proves filtering for SYN, ACK and Window probes.
+func silentState(scanType tcpscanner.ScanType) tcpscanner.PortState {
+	switch scanType {
+	case tcpscanner.FIN, tcpscanner.NULL, tcpscanner.Xmas, tcpscanner.Maimon:
+		return tcpscanner.OpenFiltered
+	default:
+		return tcpscanner.Filtered
+	}
+}
+
+// interpret maps a reply to a probe of the given scan type onto a port state.
+func interpret(scanType tcpscanner.ScanType, event TCPEvent) tcpscanner.PortState {
+	switch event.Result {
+	case TCPUnreachable:
+		return tcpscanner.Unreachable
+	case TCPFiltered:
+		return tcpscanner.Filtered
+	}
+
+	rst := event.Flags&TCP_RST != 0
+
+	switch scanType {
+	case tcpscanner.SYN:
+		switch event.Result {
+		case TCPOpen:
+			return tcpscanner.Open
+		case TCPClosed:
+			return tcpscanner.Closed
+		}
+	case tcpscanner.FIN, tcpscanner.NULL, tcpscanner.Xmas, tcpscanner.Maimon:
+		if rst {
+			return tcpscanner.Closed
+		}
+	case tcpscanner.ACK:
+		if rst {
+			return tcpscanner.Unfiltered
+		}
+	case tcpscanner.Window:
+		if rst && event.Window > 0 {
+			return tcpscanner.Open
+		}
+		if rst {
+			return tcpscanner.Closed
+		}
+	}
+
+	return tcpscanner.Filtered
+}
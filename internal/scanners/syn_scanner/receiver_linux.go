@@ -0,0 +1,191 @@
+package synscanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const ReadTimeout = 100 * time.Millisecond
+
+// ReceiveBuffer is the receive buffer asked for on every receiver socket.
+// The default only holds ~128 replies, which batched sending overruns.
+const ReceiveBuffer = 4 << 20
+
+// Receiver reads replies for a single scan from raw TCP and ICMP sockets,
+// plus a packet socket for IPv6 once EnableIPv6 is called. Only segments
+// addressed to the scan's source port that acknowledge one of its cookies,
+// and ICMP errors quoting one of its probes, are delivered.
+type Receiver struct {
+	fd     int
+	icmpFD int
+	ip6FD  int
+	corr   Correlator
+
+	recorder PacketRecorder
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func NewReceiver(port uint16, cookies *Cookies, flags TCPFlags) (*Receiver, error) {
+	fd, err := rawSocket(syscall.IPPROTO_TCP)
+	if err != nil {
+		return nil, err
+	}
+
+	icmpFD, err := rawSocket(syscall.IPPROTO_ICMP)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	r := &Receiver{
+		fd:     fd,
+		icmpFD: icmpFD,
+		ip6FD:  -1,
+		corr:   Correlator{Port: port, Cookies: cookies, Flags: flags},
+	}
+	return r, nil
+}
+
+func rawSocket(proto int) (int, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, proto)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create receiver socket: %w", err)
+	}
+
+	return configureSocket(fd)
+}
+
+// EnableIPv6 opens a packet socket for IPv6 replies. Raw AF_INET6 sockets
+// strip the IPv6 header, and with it the hop limit and extension headers,
+// so whole IPv6 packets are read at the link layer instead.
+func (r *Receiver) EnableIPv6() error {
+	if r.ip6FD >= 0 {
+		return nil
+	}
+
+	proto := int(htons(syscall.ETH_P_IPV6))
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, proto)
+	if err != nil {
+		return fmt.Errorf("failed to create ipv6 receiver socket: %w", err)
+	}
+
+	fd, err = configureSocket(fd)
+	if err != nil {
+		return err
+	}
+	r.ip6FD = fd
+	return nil
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+func configureSocket(fd int) (int, error) {
+	tv := syscall.NsecToTimeval(ReadTimeout.Nanoseconds())
+	err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+	if err != nil {
+		syscall.Close(fd)
+		return 0, fmt.Errorf("failed to set receiver timeout: %w", err)
+	}
+
+	// SO_RCVBUFFORCE ignores rmem_max but needs CAP_NET_ADMIN. Either way a
+	// smaller buffer only costs replies under load, so failures are ignored.
+	if syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUFFORCE, ReceiveBuffer) != nil {
+		_ = syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, ReceiveBuffer)
+	}
+
+	return fd, nil
+}
+
+func (r *Receiver) BindToDevice(name string) error {
+	for _, fd := range r.fds() {
+		if err := syscall.BindToDevice(fd, name); err != nil {
+			return fmt.Errorf("failed to bind receiver to '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SetRecorder hands every accepted packet to rec.
+func (r *Receiver) SetRecorder(rec PacketRecorder) {
+	r.recorder = rec
+}
+
+// SetDeadline makes Run return once t has passed. It is normally called
+// after the last probe has been sent.
+func (r *Receiver) SetDeadline(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deadline = t
+}
+
+func (r *Receiver) expired() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.deadline.IsZero() && time.Now().After(r.deadline)
+}
+
+// Run delivers classified events on out until ctx is cancelled or the
+// deadline passes, then closes out.
+func (r *Receiver) Run(ctx context.Context, out chan<- TCPEvent) {
+	var wg sync.WaitGroup
+	for _, fd := range r.fds() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.read(ctx, fd, out)
+		}()
+	}
+	wg.Wait()
+	close(out)
+}
+
+func (r *Receiver) read(ctx context.Context, fd int, out chan<- TCPEvent) {
+	buf := make([]byte, 65535)
+
+	for ctx.Err() == nil && !r.expired() {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			continue
+		}
+
+		event, ok := r.corr.Accept(buf[:n])
+		if !ok {
+			continue
+		}
+		event.Time = time.Now()
+		if r.recorder != nil {
+			_ = r.recorder.WritePacket(event.Time, buf[:n])
+		}
+
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Receiver) fds() []int {
+	fds := []int{r.fd, r.icmpFD}
+	if r.ip6FD >= 0 {
+		fds = append(fds, r.ip6FD)
+	}
+	return fds
+}
+
+func (r *Receiver) Close() error {
+	var err error
+	for _, fd := range r.fds() {
+		if cerr := syscall.Close(fd); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package synscanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiverDeadline(t *testing.T) {
+	recv, err := NewReceiver(40000, NewCookies(), TCPFlags{SYN: 1})
+	if err != nil {
+		t.Skipf("raw sockets unavailable: %s", err)
+	}
+	defer recv.Close()
+
+	events := make(chan TCPEvent)
+	start := time.Now()
+	recv.SetDeadline(start.Add(200 * time.Millisecond))
+	go recv.Run(context.Background(), events)
+
+	// Test: Run stops listening once the deadline passes
+	select {
+	case _, ok := <-events:
+		require.False(t, ok)
+	case <-time.After(2 * time.Second):
+		t.Fatal("receiver still running long after its deadline")
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
@@ -0,0 +1,125 @@
+package synscanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+
+	"github.com/CodeZeroSugar/go-scan/internal/pcap"
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+// Replay runs a scan against a capture instead of the network. The probes
+// for hosts and ports are built exactly as Scan builds them, so opts must
+// carry the CookieKey and SourcePort the capture was recorded with. Probes
+// found in the capture count as sent at their capture time, every other
+// packet goes through the same correlation and classification as a live
+// reply, and probes left unanswered at the end of the capture get the scan
+// type's silent state. Nothing is ever sent.
+func Replay(ctx context.Context, r *pcap.Reader, hosts []net.IP, ports []int, opts Options, resultQueue chan tcpscanner.PortScanResults) error {
+	if opts.CookieKey == nil || opts.SourcePort == 0 {
+		return errors.New("replay needs the cookie key and source port of the capture")
+	}
+
+	s, err := newScan(opts, nil, resultQueue)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.build(hosts, ports, replaySource(opts.Source)); err != nil {
+		return err
+	}
+
+	packets, err := readAll(r)
+	if err != nil {
+		return err
+	}
+
+	corr := Correlator{Port: s.srcPort, Cookies: s.cookies, Flags: s.flags}
+
+	for _, pkt := range packets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if key, ok := outgoing(pkt.Data, s.srcPort); ok && s.tracker.markSent(key, pkt.Timestamp) {
+			continue
+		}
+
+		event, ok := corr.Accept(pkt.Data)
+		if !ok {
+			continue
+		}
+		event.Time = pkt.Timestamp
+
+		if _, res, ok := s.handle(event); ok {
+			resultQueue <- res
+		}
+	}
+
+	s.tracker.flush()
+
+	return nil
+}
+
+// readAll returns the packets of a capture in timestamp order. A scan's
+// sender and receiver record concurrently, so a reply can be written to the
+// file ahead of the probe that caused it.
+func readAll(r *pcap.Reader) ([]pcap.Packet, error) {
+	var packets []pcap.Packet
+	for {
+		pkt, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read capture: %w", err)
+		}
+		packets = append(packets, pkt)
+	}
+
+	sort.SliceStable(packets, func(i, j int) bool {
+		return packets[i].Timestamp.Before(packets[j].Timestamp)
+	})
+
+	return packets, nil
+}
+
+// outgoing returns the flow of a TCP packet sent from our scan port.
+func outgoing(buf []byte, srcPort uint16) (flow, bool) {
+	if len(buf) == 0 {
+		return flow{}, false
+	}
+
+	var event TCPEvent
+	switch buf[0] >> 4 {
+	case 4:
+		event = ParseTCP(buf)
+	case 6:
+		event = ParseTCP6(buf)
+	default:
+		return flow{}, false
+	}
+	if event.SrcPort != srcPort || !event.DstIP.IsValid() {
+		return flow{}, false
+	}
+
+	return flow{ip: event.DstIP, port: event.DstPort, srcPort: event.SrcPort}, true
+}
+
+// replaySource stands in for SourceSelector: the probes are never sent, so
+// any address of the right family will do.
+func replaySource(addr net.IP) func(net.IP) (net.IP, error) {
+	return func(dst net.IP) (net.IP, error) {
+		if addr != nil && (addr.To4() == nil) == (dst.To4() == nil) {
+			return addr, nil
+		}
+		if dst.To4() != nil {
+			return net.IPv4zero.To4(), nil
+		}
+		return net.IPv6unspecified, nil
+	}
+}
@@ -0,0 +1,78 @@
+package synscanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/CodeZeroSugar/go-scan/internal/pcap"
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testdata/syn_scan.pcap is a SYN scan of 127.0.0.1 and ::1 on ports 8080,
+// 8081 and 8083 with the options below. 8081 listened on 127.0.0.1 only and
+// 8083 on ::1 only.
+var fixtureOptions = Options{
+	ScanType:   tcpscanner.SYN,
+	CookieKey:  []byte("go-scan replay fixture"),
+	SourcePort: 40000,
+}
+
+func replayFixture(t *testing.T, hosts []net.IP, opts Options) []string {
+	t.Helper()
+
+	r, err := pcap.Open("testdata/syn_scan.pcap")
+	require.NoError(t, err)
+	defer r.Close()
+
+	results := make(chan tcpscanner.PortScanResults, 64)
+	require.NoError(t, Replay(context.Background(), r, hosts, []int{8080, 8081, 8083}, opts, results))
+	close(results)
+
+	var got []string
+	for res := range results {
+		line := fmt.Sprintf("%s:%d %s", res.TargetIP, res.Port, res.State)
+		if res.OS != "" {
+			line += " " + res.OS
+		}
+		got = append(got, line)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestReplay(t *testing.T) {
+	hosts := []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1"), net.ParseIP("192.0.2.1")}
+
+	// Test: every probe in the capture is classified from its reply, and a
+	// host the capture never saw falls back to the silent state
+	assert.Equal(t, []string{
+		"127.0.0.1:8080 Closed",
+		"127.0.0.1:8081 Open Linux (0 hops)",
+		"127.0.0.1:8083 Closed",
+		"192.0.2.1:8080 Filtered",
+		"192.0.2.1:8081 Filtered",
+		"192.0.2.1:8083 Filtered",
+		"::1:8080 Closed",
+		"::1:8081 Closed",
+		"::1:8083 Open Linux (0 hops)",
+	}, replayFixture(t, hosts, fixtureOptions))
+
+	// Test: replies whose cookies don't match our key are ignored
+	opts := fixtureOptions
+	opts.CookieKey = []byte("some other key")
+	got := replayFixture(t, hosts[:1], opts)
+	assert.Equal(t, []string{
+		"127.0.0.1:8080 Filtered",
+		"127.0.0.1:8081 Filtered",
+		"127.0.0.1:8083 Filtered",
+	}, got)
+
+	// Test: the capture's scan parameters are required
+	err := Replay(context.Background(), nil, hosts, []int{80}, Options{ScanType: tcpscanner.SYN}, nil)
+	assert.Error(t, err)
+}
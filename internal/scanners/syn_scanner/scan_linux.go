@@ -0,0 +1,115 @@
+package synscanner
+
+import (
+	"context"
+	"net"
+	"time"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+// Scan sends a probe of type opts.ScanType to every port on every host and
+// reports one result per host/port pair on resultQueue. Each probe carries a
+// cookie over its flow, and replies that don't echo a cookie are ignored.
+// Unanswered probes are resent up to opts.Retries times, backing off from a
+// timeout derived from each host's smoothed round-trip time, and ports that
+// still never answer get the scan type's silent state.
+//
+// If ctx is done first, Scan stops sending, leaves ports still awaiting a
+// reply unreported and returns ctx's error.
+func Scan(ctx context.Context, hosts []net.IP, ports []int, opts Options, resultQueue chan tcpscanner.PortScanResults) error {
+	sender := NewSender(opts.Interface, opts.Limiter)
+	defer sender.Close()
+
+	s, err := newScan(opts, sender, resultQueue)
+	if err != nil {
+		return err
+	}
+
+	recv, err := NewReceiver(s.srcPort, s.cookies, s.flags)
+	if err != nil {
+		return err
+	}
+	defer recv.Close()
+
+	for _, host := range hosts {
+		if host.To4() == nil {
+			if err := recv.EnableIPv6(); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if opts.Recorder != nil {
+		recv.SetRecorder(opts.Recorder)
+		sender.SetRecorder(opts.Recorder)
+	}
+
+	if opts.Interface != "" {
+		if err := recv.BindToDevice(opts.Interface); err != nil {
+			return err
+		}
+	}
+
+	sources := &SourceSelector{Addr: opts.Source, Interface: opts.Interface}
+	probes, err := s.build(hosts, ports, sources.SourceFor)
+	if err != nil {
+		return err
+	}
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan TCPEvent, 256)
+	go recv.Run(scanCtx, events)
+
+	// The tracker normally ends the scan once every probe is resolved. The
+	// receiver's deadline only stops it from listening past the longest the
+	// last probes could still be waiting for replies.
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		s.sendAll(scanCtx, probes)
+		recv.SetDeadline(s.tracker.lastReply(time.Now()))
+	}()
+	scheduled := make(chan struct{})
+	go func() {
+		defer close(scheduled)
+		s.tracker.schedule(scanCtx, sent, cancel)
+	}()
+
+	for event := range events {
+		pr, res, ok := s.handle(event)
+		if !ok {
+			continue
+		}
+		if event.Result == TCPOpen {
+			sendRST(scanCtx, sender, pr.packet, event)
+		}
+		resultQueue <- res
+	}
+
+	cancel()
+	<-sent
+	<-scheduled
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.tracker.flush()
+
+	return nil
+}
+
+func sendRST(ctx context.Context, sender *Sender, p *Packet, event TCPEvent) {
+	rst := &Packet{
+		IPSeg:       p.IPSeg,
+		IP6Seg:      p.IP6Seg,
+		TCPSeg:      p.TCPSeg.BuildRST(event.Ack, event.Seq+1),
+		Destination: p.Destination,
+	}
+	if err := rst.GeneratePacket(); err != nil {
+		return
+	}
+	_, _ = sender.Send(ctx, rst)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package synscanner
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+)
+
+// Scan needs raw sockets and sendmmsg, which only Linux provides here.
+func Scan(ctx context.Context, hosts []net.IP, ports []int, opts Options, resultQueue chan tcpscanner.PortScanResults) error {
+	return errors.New("raw packet scans are only supported on Linux")
+}
@@ -0,0 +1,214 @@
+package synscanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
+	"golang.org/x/sys/unix"
+)
+
+// ipv6HdrIncl is IPV6_HDRINCL from linux/in6.h; the syscall package doesn't
+// export it.
+const ipv6HdrIncl = 36
+
+// mmsghdr mirrors struct mmsghdr, which x/sys/unix doesn't define. Go pads
+// it to Msghdr's alignment just as C does, so it needs no explicit padding
+// on either 32 or 64-bit platforms.
+type mmsghdr struct {
+	hdr unix.Msghdr
+	len uint32
+}
+
+// Sender transmits crafted packets over one long-lived raw socket per
+// address family, batching them with sendmmsg and pacing them with a
+// limiter. It is safe for concurrent use.
+type Sender struct {
+	mu       sync.Mutex
+	fd4      int
+	fd6      int
+	device   string
+	recorder PacketRecorder
+
+	limiter *timing.Limiter
+
+	msgs   [MaxBatch]mmsghdr
+	iovs   [MaxBatch]unix.Iovec
+	addrs4 [MaxBatch]unix.RawSockaddrInet4
+	addrs6 [MaxBatch]unix.RawSockaddrInet6
+}
+
+// NewSender returns a sender bound to device, if set, that paces its
+// batches with limiter, or sends as fast as it can when limiter is nil.
+// Sockets are opened on first use.
+func NewSender(device string, limiter *timing.Limiter) *Sender {
+	return &Sender{fd4: -1, fd6: -1, device: device, limiter: limiter}
+}
+
+func (s *Sender) SetRecorder(recorder PacketRecorder) {
+	s.recorder = recorder
+}
+
+// Send transmits packets in order and returns how many were sent. On error
+// packets[n] is the one that failed and the rest were not attempted. If ctx
+// is done while waiting on the limiter, Send returns ctx's error.
+func (s *Sender) Send(ctx context.Context, packets ...*Packet) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := 0
+	for sent < len(packets) {
+		batch := s.nextBatch(packets[sent:])
+
+		fd, err := s.socket(batch[0].IsIPv6())
+		if err != nil {
+			return sent, err
+		}
+
+		if err := s.limiter.WaitN(ctx, len(batch)); err != nil {
+			return sent, err
+		}
+		ts := time.Now()
+		n, err := s.sendBatch(fd, batch)
+		if s.recorder != nil {
+			for _, p := range batch[:n] {
+				_ = s.recorder.WritePacket(ts, p.Bytes)
+			}
+		}
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// BatchSize is how many packets the sender transmits at once, capped so a
+// batch never spans more than the limiter's burst window. Callers timing
+// replies should hand over packets in batches of this size, since Send may
+// sleep before each one.
+func (s *Sender) BatchSize() int {
+	if rate := s.limiter.Rate(); rate > 0 {
+		return min(MaxBatch, max(1, int(rate*timing.BurstWindow.Seconds())))
+	}
+	return MaxBatch
+}
+
+// nextBatch returns the leading packets of one address family.
+func (s *Sender) nextBatch(packets []*Packet) []*Packet {
+	limit := s.BatchSize()
+	v6 := packets[0].IsIPv6()
+	n := 1
+	for n < len(packets) && n < limit && packets[n].IsIPv6() == v6 {
+		n++
+	}
+	return packets[:n]
+}
+
+func (s *Sender) socket(v6 bool) (int, error) {
+	fd, family, level, opt := &s.fd4, syscall.AF_INET, syscall.IPPROTO_IP, syscall.IP_HDRINCL
+	if v6 {
+		fd, family, level, opt = &s.fd6, syscall.AF_INET6, syscall.IPPROTO_IPV6, ipv6HdrIncl
+	}
+	if *fd >= 0 {
+		return *fd, nil
+	}
+
+	sock, err := syscall.Socket(family, syscall.SOCK_RAW, syscall.IPPROTO_TCP)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create socket: %w", err)
+	}
+	if err := syscall.SetsockoptInt(sock, level, opt, 1); err != nil {
+		syscall.Close(sock)
+		return -1, fmt.Errorf("failed to set socket opt: %w", err)
+	}
+	if s.device != "" {
+		if err := syscall.BindToDevice(sock, s.device); err != nil {
+			syscall.Close(sock)
+			return -1, fmt.Errorf("failed to bind socket to '%s': %w", s.device, err)
+		}
+	}
+
+	*fd = sock
+	return sock, nil
+}
+
+// sendBatch hands a batch of one address family to sendmmsg, falling back
+// to one sendto per packet on kernels without it.
+func (s *Sender) sendBatch(fd int, batch []*Packet) (int, error) {
+	for i, p := range batch {
+		msg := &s.msgs[i]
+		*msg = mmsghdr{}
+
+		if p.IsIPv6() {
+			s.addrs6[i] = unix.RawSockaddrInet6{Family: unix.AF_INET6, Addr: [16]byte(p.Destination.To16())}
+			msg.hdr.Name = (*byte)(unsafe.Pointer(&s.addrs6[i]))
+			msg.hdr.Namelen = unix.SizeofSockaddrInet6
+		} else {
+			s.addrs4[i] = unix.RawSockaddrInet4{Family: unix.AF_INET, Addr: [4]byte(p.Destination.To4())}
+			msg.hdr.Name = (*byte)(unsafe.Pointer(&s.addrs4[i]))
+			msg.hdr.Namelen = unix.SizeofSockaddrInet4
+		}
+
+		s.iovs[i].Base = &p.Bytes[0]
+		s.iovs[i].SetLen(len(p.Bytes))
+		msg.hdr.Iov = &s.iovs[i]
+		msg.hdr.SetIovlen(1)
+	}
+	defer runtime.KeepAlive(batch)
+
+	sent := 0
+	for sent < len(batch) {
+		n, _, errno := unix.Syscall6(unix.SYS_SENDMMSG, uintptr(fd),
+			uintptr(unsafe.Pointer(&s.msgs[sent])), uintptr(len(batch)-sent), 0, 0, 0)
+		switch {
+		case errno == unix.EINTR:
+			continue
+		case errno == unix.ENOSYS:
+			n, err := s.sendEach(fd, batch[sent:])
+			return sent + n, err
+		case errno != 0:
+			return sent, fmt.Errorf("failed to send packet over raw socket: %w", errno)
+		}
+		sent += int(n)
+	}
+
+	return sent, nil
+}
+
+func (s *Sender) sendEach(fd int, batch []*Packet) (int, error) {
+	for i, p := range batch {
+		var to syscall.Sockaddr
+		if p.IsIPv6() {
+			to = &syscall.SockaddrInet6{Addr: [16]byte(p.Destination.To16())}
+		} else {
+			to = &syscall.SockaddrInet4{Addr: [4]byte(p.Destination.To4())}
+		}
+		if err := syscall.Sendto(fd, p.Bytes, 0, to); err != nil {
+			return i, fmt.Errorf("failed to send packet over raw socket: %w", err)
+		}
+	}
+	return len(batch), nil
+}
+
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, fd := range []*int{&s.fd4, &s.fd6} {
+		if *fd < 0 {
+			continue
+		}
+		errs = append(errs, syscall.Close(*fd))
+		*fd = -1
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,19 @@
+package synscanner
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestMmsghdrLayout(t *testing.T) {
+	// Test: mmsghdr matches the kernel's struct mmsghdr, a msghdr followed
+	// by an unsigned int and padded to msghdr's alignment, so sendmmsg reads
+	// every element of the array from the right place
+	align := unsafe.Alignof(unix.Msghdr{})
+	want := (unsafe.Sizeof(unix.Msghdr{}) + 4 + align - 1) / align * align
+	assert.Equal(t, want, unsafe.Sizeof(mmsghdr{}))
+	assert.Equal(t, uintptr(unix.SizeofMsghdr), unsafe.Offsetof(mmsghdr{}.len))
+}
@@ -0,0 +1,97 @@
+package synscanner
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// SourceSelector picks the source address for probes to each destination.
+// An explicit Addr always wins, then the first address of Interface in the
+// destination's family. Otherwise the kernel routing table is consulted by
+// connecting a UDP socket to the destination and reading back its local
+// address.
+type SourceSelector struct {
+	Addr      net.IP
+	Interface string
+
+	mu    sync.Mutex
+	cache map[string]net.IP
+}
+
+func (s *SourceSelector) SourceFor(dst net.IP) (net.IP, error) {
+	if s.Addr != nil {
+		if (s.Addr.To4() == nil) != (dst.To4() == nil) {
+			return nil, fmt.Errorf("source address %s can't reach %s: different address families", s.Addr, dst)
+		}
+		return s.Addr, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cache == nil {
+		s.cache = make(map[string]net.IP)
+	}
+	if ip, ok := s.cache[dst.String()]; ok {
+		return ip, nil
+	}
+
+	var ip net.IP
+	var err error
+	if s.Interface != "" {
+		ip, err = interfaceAddr(s.Interface, dst.To4() == nil)
+	} else {
+		ip, err = routeSource(dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache[dst.String()] = ip
+	return ip, nil
+}
+
+func routeSource(dst net.IP) (net.IP, error) {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: dst, Port: 9})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find source address for %s: %w", dst, err)
+	}
+	defer conn.Close()
+
+	ip := conn.LocalAddr().(*net.UDPAddr).IP
+	if v4 := ip.To4(); v4 != nil {
+		return v4, nil
+	}
+	return ip, nil
+}
+
+func interfaceAddr(name string, v6 bool) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interface '%s': %w", name, err)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses on '%s': %w", name, err)
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if !v6 && ipNet.IP.To4() != nil {
+			return ipNet.IP.To4(), nil
+		}
+		if v6 && ipNet.IP.To4() == nil && !ipNet.IP.IsLinkLocalUnicast() {
+			return ipNet.IP, nil
+		}
+	}
+
+	if v6 {
+		return nil, fmt.Errorf("interface '%s' has no global IPv6 address", name)
+	}
+	return nil, fmt.Errorf("interface '%s' has no IPv4 address", name)
+}
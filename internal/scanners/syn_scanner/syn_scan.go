@@ -1,63 +1,287 @@
+// Package synscanner provides GoScan's raw packet port scanning functions:
+// half-open SYN scans and the FIN, NULL, Xmas, ACK, Window and Maimon probes
+// built on the same packet and receiver code
 package synscanner
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"time"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
 )
 
 const (
-	TargetIP   = "192.168.0.168"
-	TargetPort = 80
+	SourcePort     = 12345
+	DefaultRetries = 1
+	// MaxWindow caps the probes in flight to one host.
+	MaxWindow = 1024
+	// MaxBatch is the most packets handed to the kernel in one sendmmsg call.
+	MaxBatch = 64
+
+	ephemeralLow  = 32768
+	ephemeralHigh = 60999
 )
 
-func main() {
-	fmt.Printf("Sending TCP SYN to %s:%d\n", TargetIP, TargetPort)
+// DefaultSYNOptions are sent with SYN probes when Options.TCPOptions is
+// nil. Some middleboxes drop SYNs that carry no options at all.
+var DefaultSYNOptions = []TCPOption{MSSOption(1460)}
 
-	recv, err := NewReceiver()
-	if err != nil {
-		log.Fatalf("failed to establish receiver: %s", err)
+type Options struct {
+	ScanType   tcpscanner.ScanType
+	Source     net.IP
+	Interface  string
+	Retries    int
+	TCPOptions []TCPOption
+	Recorder   PacketRecorder
+
+	// Limiter paces every probe and RST sent; nil sends as fast as possible.
+	Limiter *timing.Limiter
+	// Congestion bounds the probes in flight to each host; nil sends every
+	// probe without waiting for replies.
+	Congestion *timing.Congestion
+	// HostTimeout abandons a host's remaining ports once this long has
+	// passed since its first probe; 0 never does.
+	HostTimeout time.Duration
+	// Skip reports host/port pairs to leave out of the scan, such as those
+	// a resumed scan already finished. They get no probe and no result.
+	Skip func(host net.IP, port int) bool
+
+	// CookieKey and SourcePort are random when unset. Fixing them makes a
+	// scan's probes reproducible, which Replay relies on.
+	CookieKey  []byte
+	SourcePort uint16
+}
+
+type flow struct {
+	ip      netip.Addr
+	port    uint16
+	srcPort uint16
+}
+
+type probe struct {
+	target   net.IP
+	addr     netip.Addr
+	port     int
+	packet   *Packet
+	attempts int
+	sentAt   time.Time
+}
+
+func (pr *probe) flow() flow {
+	return flow{
+		ip:      pr.addr,
+		port:    uint16(pr.port),
+		srcPort: pr.packet.TCPSeg.SrcPort,
 	}
+}
 
-	tcpChan := make(chan TCPEvent)
+func (pr *probe) result(state tcpscanner.PortState, err error) tcpscanner.PortScanResults {
+	return tcpscanner.PortScanResults{
+		TargetIP:  pr.target,
+		Port:      pr.port,
+		State:     state,
+		ErrorInfo: err,
+		Attempts:  pr.attempts,
+	}
+}
 
-	go recv.Receive(tcpChan)
+// scan holds the state shared by live scans and replays: how probes are
+// built and how their replies turn into results.
+type scan struct {
+	opts         Options
+	flags        TCPFlags
+	tcpOptions   []TCPOption
+	cookies      *Cookies
+	srcPort      uint16
+	tracker      *tracker
+	fingerprints *Fingerprinter
+}
 
-	p, err := NewPacket("127.0.0.1", TargetIP, TargetPort)
+func newScan(opts Options, sender packetSender, resultQueue chan tcpscanner.PortScanResults) (*scan, error) {
+	flags, err := probeFlags(opts.ScanType)
 	if err != nil {
-		log.Fatalf("failed to create new packet: %s", err)
+		return nil, err
 	}
 
-	p.GeneratePacket()
+	tcpOptions := opts.TCPOptions
+	if tcpOptions == nil && opts.ScanType == tcpscanner.SYN {
+		tcpOptions = DefaultSYNOptions
+	}
 
-	err = p.SendPacket()
-	if err != nil {
-		log.Printf("failed to send packet: %s", err)
+	cookies := NewCookies()
+	if opts.CookieKey != nil {
+		cookies = NewCookiesWithKey(opts.CookieKey)
 	}
 
-	event := <-tcpChan
-	switch event.Classify() {
-	case TCPOpen:
-		fmt.Println("Port Open")
+	srcPort := opts.SourcePort
+	if srcPort == 0 {
+		srcPort = uint16(ephemeralLow + rand.IntN(ephemeralHigh-ephemeralLow+1))
+	}
 
-		rst := TCPSegment{
-			SrcPort:    p.TCPSeg.SrcPort,
-			DstPort:    p.TCPSeg.DstPort,
-			SeqNumber:  event.Ack,
-			AckNumber:  event.Seq + 1,
-			DataOffset: 5,
-			Flags: TCPFlags{
-				RST: 1,
-				ACK: 1,
-			},
-		}
+	return &scan{
+		opts:         opts,
+		flags:        flags,
+		tcpOptions:   tcpOptions,
+		cookies:      cookies,
+		srcPort:      srcPort,
+		tracker:      newTracker(sender, opts, resultQueue),
+		fingerprints: NewFingerprinter(),
+	}, nil
+}
 
-		p.TCPSeg = rst
-		p.GeneratePacket()
-		err = p.SendPacket()
+// build creates and tracks one probe per host/port pair, taking each host's
+// source address from source.
+func (s *scan) build(hosts []net.IP, ports []int, source func(net.IP) (net.IP, error)) ([]*probe, error) {
+	var probes []*probe
+
+	for _, host := range hosts {
+		src, err := source(host)
 		if err != nil {
-			log.Printf("failed to send RST")
+			return nil, err
+		}
+		addr, _ := netip.AddrFromSlice(host)
+		addr = addr.Unmap()
+		for _, port := range ports {
+			if s.opts.Skip != nil && s.opts.Skip(host, port) {
+				continue
+			}
+			p, err := NewPacket(src.String(), host.String(), uint16(port))
+			if err != nil {
+				return nil, err
+			}
+			p.TCPSeg.SrcPort = s.srcPort
+			p.TCPSeg.Flags = s.flags
+			p.TCPSeg.Options = s.tcpOptions
+			p.TCPSeg.SeqNumber = s.cookies.Seq(addr, p.TCPSeg.DstPort, s.srcPort)
+			if s.flags.ACK == 1 {
+				p.TCPSeg.AckNumber = p.TCPSeg.SeqNumber
+			}
+			if err := p.GeneratePacket(); err != nil {
+				return nil, err
+			}
+
+			pr := &probe{target: p.Destination, addr: addr, port: port, packet: p}
+			s.tracker.add(pr)
+			probes = append(probes, pr)
+		}
+	}
+
+	return probes, nil
+}
+
+// sendAll transmits every probe once. With congestion control, probes wait
+// for room in their host's window, and hosts take turns so a slow one
+// doesn't hold up the rest. If the limiter has a minimum rate, probes go
+// out past full windows whenever sending falls behind it.
+func (s *scan) sendAll(ctx context.Context, probes []*probe) {
+	cc := s.opts.Congestion
+	if cc == nil {
+		for len(probes) > 0 && ctx.Err() == nil {
+			n := min(MaxBatch, len(probes))
+			s.tracker.send(ctx, probes[:n]...)
+			probes = probes[n:]
+		}
+		return
+	}
+
+	var hosts []string
+	queues := make(map[string][]*probe)
+	for _, pr := range probes {
+		host := pr.target.String()
+		if _, ok := queues[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		queues[host] = append(queues[host], pr)
+	}
+
+	floor := s.opts.Limiter.Min()
+	start := time.Now()
+	sent := 0
+	var tick <-chan time.Time
+	if floor > 0 {
+		ticker := time.NewTicker(scheduleInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for len(hosts) > 0 {
+		var batch []*probe
+		waiting := hosts[:0]
+		now := time.Now()
+		for _, host := range hosts {
+			queue := queues[host]
+			if s.tracker.hosts.Expired(host, now) {
+				s.tracker.abandon(queue...)
+				continue
+			}
+			for len(queue) > 0 && len(batch) < MaxBatch && cc.TryAcquire(host) {
+				batch = append(batch, queue[0])
+				queue = queue[1:]
+			}
+			queues[host] = queue
+			if len(queue) > 0 {
+				waiting = append(waiting, host)
+			}
+		}
+		hosts = waiting
+
+		if len(batch) == 0 && floor > 0 {
+			behind := int(floor*time.Since(start).Seconds()) - sent
+			batch = force(cc, hosts, queues, min(behind, MaxBatch))
+		}
+		if len(batch) > 0 {
+			sent += len(batch)
+			s.tracker.send(ctx, batch...)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-cc.Ready():
+		case <-tick:
+		}
+	}
+}
+
+// force takes up to n probes from the queues past their hosts' windows,
+// one host at a time.
+func force(cc *timing.Congestion, hosts []string, queues map[string][]*probe, n int) []*probe {
+	var batch []*probe
+	for len(batch) < n {
+		took := false
+		for _, host := range hosts {
+			queue := queues[host]
+			if len(queue) == 0 || len(batch) == n {
+				continue
+			}
+			cc.Force(host)
+			batch = append(batch, queue[0])
+			queues[host] = queue[1:]
+			took = true
+		}
+		if !took {
+			break
 		}
-	case TCPClosed:
-		fmt.Println("Port Closed")
 	}
+	return batch
+}
+
+// handle resolves the probe answered by event and returns its result.
+func (s *scan) handle(event TCPEvent) (*probe, tcpscanner.PortScanResults, bool) {
+	pr, ok := s.tracker.resolve(event)
+	if !ok {
+		return nil, tcpscanner.PortScanResults{}, false
+	}
+
+	res := pr.result(interpret(s.opts.ScanType, event), nil)
+	if event.Result == TCPOpen {
+		res.OS = s.fingerprints.Observe(event).String()
+	}
+
+	return pr, res, true
 }
@@ -0,0 +1,43 @@
+package synscanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendUnanswered(t *testing.T, limiter *timing.Limiter) int {
+	t.Helper()
+
+	sender := &fakeSender{}
+	opts := Options{ScanType: tcpscanner.SYN, Limiter: limiter, Congestion: timing.NewCongestion(1)}
+	s, probes, _ := newTestScan(t, opts, sender, 50)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	s.sendAll(ctx, probes)
+
+	return sender.total()
+}
+
+func TestSendAllMinRate(t *testing.T) {
+	// Test: without a minimum rate, a full window holds back the rest
+	assert.Equal(t, 1, sendUnanswered(t, nil))
+
+	// Test: a minimum rate sends past the window when it falls behind
+	assert.Equal(t, 50, sendUnanswered(t, timing.NewLimiter(1000, 0)))
+}
+
+func TestLastReply(t *testing.T) {
+	s, err := newScan(Options{ScanType: tcpscanner.SYN, Retries: 2}, &fakeSender{}, nil)
+	require.NoError(t, err)
+	sent := time.Now()
+
+	// Test: the receiver outlives every backoff of the last probes sent
+	assert.Equal(t, sent.Add(3*timing.MaxTimeout+scheduleInterval), s.tracker.lastReply(sent))
+}
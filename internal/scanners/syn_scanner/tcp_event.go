@@ -0,0 +1,117 @@
+package synscanner
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"time"
+)
+
+const (
+	TCP_FIN = 0x01
+	TCP_SYN = 0x02
+	TCP_RST = 0x04
+	TCP_PSH = 0x08
+	TCP_ACK = 0x10
+)
+
+type TCPResult int
+
+const (
+	TCPUnknown TCPResult = iota
+	TCPOpen
+	TCPClosed
+	TCPFiltered
+	TCPUnreachable
+)
+
+type TCPEvent struct {
+	SrcIP   netip.Addr
+	DstIP   netip.Addr
+	SrcPort uint16
+	DstPort uint16
+	Seq     uint32
+	Ack     uint32
+	Flags   uint16
+	Window  uint16
+	Options []TCPOption
+	TTL     uint8
+	IPID    uint16
+	DF      bool
+	Result  TCPResult
+	Time    time.Time
+}
+
+func ParseTCP(buf []byte) TCPEvent {
+	if len(buf) < 20 {
+		return TCPEvent{}
+	}
+
+	version := buf[0] >> 4
+	if version != 4 {
+		return TCPEvent{}
+	}
+
+	protocol := buf[9]
+	if protocol != protoTCP {
+		return TCPEvent{}
+	}
+
+	ihl := int(buf[0]&0x0F) * 4
+	if len(buf) < ihl {
+		return TCPEvent{}
+	}
+
+	event, ok := parseTCPHeader(buf[ihl:])
+	if !ok {
+		return TCPEvent{}
+	}
+
+	event.SrcIP = netip.AddrFrom4([4]byte(buf[12:16]))
+	event.DstIP = netip.AddrFrom4([4]byte(buf[16:20]))
+	event.IPID = binary.BigEndian.Uint16(buf[4:6])
+	event.DF = buf[6]&0x40 != 0
+	event.TTL = buf[8]
+
+	return event
+}
+
+// parseTCPHeader fills in the TCP fields of an event from a segment. The
+// caller sets the addressing fields from whichever IP header carried it.
+func parseTCPHeader(tcp []byte) (TCPEvent, bool) {
+	if len(tcp) < 20 {
+		return TCPEvent{}, false
+	}
+
+	var options []TCPOption
+	dataOffset := int(tcp[12]>>4) * 4
+	if dataOffset > 20 && dataOffset <= len(tcp) {
+		options, _ = ParseTCPOptions(tcp[20:dataOffset])
+	}
+
+	event := TCPEvent{
+		SrcPort: binary.BigEndian.Uint16(tcp[0:2]),
+		DstPort: binary.BigEndian.Uint16(tcp[2:4]),
+		Seq:     binary.BigEndian.Uint32(tcp[4:8]),
+		Ack:     binary.BigEndian.Uint32(tcp[8:12]),
+		Flags:   binary.BigEndian.Uint16(tcp[12:14]) & 0x01FF,
+		Window:  binary.BigEndian.Uint16(tcp[14:16]),
+		Options: options,
+	}
+
+	return event, true
+}
+
+func (t *TCPEvent) Classify() TCPResult {
+	syn := t.Flags&0x002 != 0
+	ack := t.Flags&0x010 != 0
+	rst := t.Flags&0x004 != 0
+
+	switch {
+	case syn && ack:
+		return TCPOpen
+	case rst:
+		return TCPClosed
+	default:
+		return TCPUnknown
+	}
+}
@@ -0,0 +1,122 @@
+package synscanner
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	OptEOL           = 0
+	OptNOP           = 1
+	OptMSS           = 2
+	OptWindowScale   = 3
+	OptSACKPermitted = 4
+	OptSACK          = 5
+	OptTimestamps    = 8
+)
+
+// MaxOptionsLen is the most option space a TCP header can carry (a data
+// offset of 15 words minus the fixed 20-byte header).
+const MaxOptionsLen = 40
+
+// TCPOption is a single TCP header option. EOL and NOP are one byte long
+// and carry no Data; every other kind is encoded as kind, length, data.
+type TCPOption struct {
+	Kind uint8
+	Data []byte
+}
+
+func NOPOption() TCPOption {
+	return TCPOption{Kind: OptNOP}
+}
+
+func EOLOption() TCPOption {
+	return TCPOption{Kind: OptEOL}
+}
+
+func MSSOption(mss uint16) TCPOption {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, mss)
+	return TCPOption{Kind: OptMSS, Data: data}
+}
+
+func WindowScaleOption(shift uint8) TCPOption {
+	return TCPOption{Kind: OptWindowScale, Data: []byte{shift}}
+}
+
+func SACKPermittedOption() TCPOption {
+	return TCPOption{Kind: OptSACKPermitted}
+}
+
+func TimestampsOption(value, echo uint32) TCPOption {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:4], value)
+	binary.BigEndian.PutUint32(data[4:8], echo)
+	return TCPOption{Kind: OptTimestamps, Data: data}
+}
+
+func (o TCPOption) Len() int {
+	if o.Kind == OptEOL || o.Kind == OptNOP {
+		return 1
+	}
+	return 2 + len(o.Data)
+}
+
+// MarshalOptions encodes opts and pads them with EOL bytes to a multiple of
+// four so they fit the header's data offset.
+func MarshalOptions(opts []TCPOption) ([]byte, error) {
+	var buf []byte
+	for _, o := range opts {
+		if o.Kind == OptEOL || o.Kind == OptNOP {
+			buf = append(buf, o.Kind)
+			continue
+		}
+		buf = append(buf, o.Kind, uint8(o.Len()))
+		buf = append(buf, o.Data...)
+	}
+
+	for len(buf)%4 != 0 {
+		buf = append(buf, OptEOL)
+	}
+
+	if len(buf) > MaxOptionsLen {
+		return nil, fmt.Errorf("tcp options need %d bytes, only %d fit in the header", len(buf), MaxOptionsLen)
+	}
+
+	return buf, nil
+}
+
+// ParseTCPOptions decodes the option bytes of a TCP header, stopping at the
+// first EOL. Padding after EOL is not returned.
+func ParseTCPOptions(buf []byte) ([]TCPOption, error) {
+	var opts []TCPOption
+	for i := 0; i < len(buf); {
+		kind := buf[i]
+		switch kind {
+		case OptEOL:
+			return append(opts, EOLOption()), nil
+		case OptNOP:
+			opts = append(opts, NOPOption())
+			i++
+			continue
+		}
+
+		if i+1 >= len(buf) {
+			return opts, fmt.Errorf("tcp option %d is missing its length", kind)
+		}
+		length := int(buf[i+1])
+		if length < 2 || i+length > len(buf) {
+			return opts, fmt.Errorf("tcp option %d has invalid length %d", kind, length)
+		}
+
+		var data []byte
+		if length > 2 {
+			data = make([]byte, length-2)
+			copy(data, buf[i+2:i+length])
+		}
+		opts = append(opts, TCPOption{Kind: kind, Data: data})
+		i += length
+	}
+
+	return opts, nil
+}
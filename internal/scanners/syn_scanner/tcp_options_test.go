@@ -0,0 +1,75 @@
+package synscanner
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalOptions(t *testing.T) {
+	// Test: MSS alone needs no padding
+	buf, err := MarshalOptions([]TCPOption{MSSOption(1460)})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{OptMSS, 4, 0x05, 0xb4}, buf)
+
+	// Test: window scale is padded to a word
+	buf, err = MarshalOptions([]TCPOption{WindowScaleOption(7)})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{OptWindowScale, 3, 7, OptEOL}, buf)
+
+	// Test: a typical Linux SYN option set
+	buf, err = MarshalOptions([]TCPOption{
+		MSSOption(1460), SACKPermittedOption(), TimestampsOption(1, 0), NOPOption(), WindowScaleOption(7),
+	})
+	require.NoError(t, err)
+	assert.Len(t, buf, 20)
+
+	// Test: too many options
+	var opts []TCPOption
+	for i := 0; i < 5; i++ {
+		opts = append(opts, TimestampsOption(0, 0))
+	}
+	_, err = MarshalOptions(opts)
+	require.Error(t, err)
+}
+
+func TestParseTCPOptions(t *testing.T) {
+	want := []TCPOption{MSSOption(1460), SACKPermittedOption(), TimestampsOption(7, 9), NOPOption(), WindowScaleOption(7)}
+	buf, err := MarshalOptions(want)
+	require.NoError(t, err)
+
+	got, err := ParseTCPOptions(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// Test: EOL ends parsing
+	got, err = ParseTCPOptions([]byte{OptWindowScale, 3, 7, OptEOL})
+	require.NoError(t, err)
+	assert.Equal(t, []TCPOption{WindowScaleOption(7), EOLOption()}, got)
+
+	// Test: truncated option
+	_, err = ParseTCPOptions([]byte{OptMSS, 4, 0x05})
+	require.Error(t, err)
+}
+
+func TestPacketWithOptions(t *testing.T) {
+	p, err := NewPacket("192.168.0.10", "192.168.0.168", 443)
+	require.NoError(t, err)
+	p.TCPSeg.Options = []TCPOption{MSSOption(1460), WindowScaleOption(7)}
+	require.NoError(t, p.GeneratePacket())
+
+	require.Len(t, p.Bytes, 48)
+	assert.Equal(t, uint16(48), binary.BigEndian.Uint16(p.Bytes[2:4]))
+	assert.Equal(t, uint8(7), p.Bytes[32]>>4)
+
+	// Test: checksum over the pseudo header verifies to zero
+	psh := buildPseudoHeader(p.IPSeg.SrcAddr, p.IPSeg.DstAddr, p.Bytes[20:])
+	assert.Equal(t, uint16(0), CalcChecksum(psh))
+
+	// Test: options survive a round trip through ParseTCP
+	event := ParseTCP(p.Bytes)
+	assert.Equal(t, uint16(443), event.DstPort)
+	assert.Equal(t, []TCPOption{MSSOption(1460), WindowScaleOption(7), EOLOption()}, event.Options)
+}
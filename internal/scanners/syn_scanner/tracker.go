@@ -0,0 +1,267 @@
+package synscanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
+)
+
+const scheduleInterval = 10 * time.Millisecond
+
+// packetSender transmits probes. It is nil during a replay, which sends
+// nothing.
+type packetSender interface {
+	Send(ctx context.Context, packets ...*Packet) (int, error)
+	BatchSize() int
+}
+
+// tracker owns the outstanding probes of a scan. Every probe leaves pending
+// exactly once, either answered, expired after its last retransmission,
+// abandoned when its host ran out of time or failed to send, and produces
+// exactly one result.
+type tracker struct {
+	mu      sync.Mutex
+	pending map[flow]*probe
+	rtt     *timing.RTTEstimator
+	retries int
+	silent  tcpscanner.PortState
+	results chan tcpscanner.PortScanResults
+	sender  packetSender
+	cc      *timing.Congestion
+	hosts   *timing.HostClock
+}
+
+func newTracker(sender packetSender, opts Options, results chan tcpscanner.PortScanResults) *tracker {
+	return &tracker{
+		sender:  sender,
+		cc:      opts.Congestion,
+		hosts:   timing.NewHostClock(opts.HostTimeout),
+		pending: make(map[flow]*probe),
+		rtt:     timing.NewRTTEstimator(),
+		retries: opts.Retries,
+		silent:  silentState(opts.ScanType),
+		results: results,
+	}
+}
+
+func (t *tracker) add(pr *probe) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[pr.flow()] = pr
+}
+
+// send transmits the probes that are still pending, stamping each batch
+// just before handing it to the sender so pacing doesn't count towards the
+// probes' round trips. A probe the sender fails on is reported as filtered.
+func (t *tracker) send(ctx context.Context, prs ...*probe) {
+	size := t.sender.BatchSize()
+	for len(prs) > 0 && ctx.Err() == nil {
+		n := min(size, len(prs))
+		t.sendBatch(ctx, prs[:n])
+		prs = prs[n:]
+	}
+}
+
+func (t *tracker) sendBatch(ctx context.Context, prs []*probe) {
+	var batch, expired []*probe
+	now := time.Now()
+
+	t.mu.Lock()
+	for _, pr := range prs {
+		if _, ok := t.pending[pr.flow()]; !ok {
+			continue
+		}
+		if t.hosts.Expired(pr.target.String(), now) {
+			expired = append(expired, pr)
+			continue
+		}
+		pr.attempts++
+		pr.sentAt = now
+		batch = append(batch, pr)
+	}
+	t.mu.Unlock()
+
+	t.abandon(expired...)
+	for _, pr := range batch {
+		t.hosts.Start(pr.target.String())
+	}
+
+	packets := make([]*Packet, len(batch))
+	for i, pr := range batch {
+		packets[i] = pr.packet
+	}
+
+	for len(packets) > 0 {
+		n, err := t.sender.Send(ctx, packets...)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+
+		if t.remove(batch[n]) {
+			t.release(batch[n], timing.Silent)
+			t.results <- batch[n].result(tcpscanner.Filtered, err)
+		}
+		batch, packets = batch[n+1:], packets[n+1:]
+	}
+}
+
+// markSent records a transmission of the probe for key at ts without
+// sending anything, for probes read back from a capture.
+func (t *tracker) markSent(key flow, ts time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pr, ok := t.pending[key]
+	if !ok {
+		return false
+	}
+	pr.attempts++
+	pr.sentAt = ts
+	return true
+}
+
+func (t *tracker) remove(pr *probe) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.pending[pr.flow()]; !ok {
+		return false
+	}
+	delete(t.pending, pr.flow())
+	return true
+}
+
+// resolve removes the probe answered by event. Round trips are only sampled
+// from probes that were sent once, since a reply to a retransmitted probe
+// can't be attributed to a particular attempt.
+func (t *tracker) resolve(event TCPEvent) (*probe, bool) {
+	key := flow{ip: event.SrcIP, port: event.SrcPort, srcPort: event.DstPort}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pr, ok := t.pending[key]
+	if !ok || pr.attempts == 0 {
+		return nil, false
+	}
+	delete(t.pending, key)
+
+	outcome := timing.Recovered
+	if pr.attempts == 1 {
+		t.rtt.Observe(pr.target.String(), event.Time.Sub(pr.sentAt))
+		outcome = timing.Answered
+	}
+	t.release(pr, outcome)
+
+	return pr, true
+}
+
+// release gives a finished probe's room in its host's congestion window
+// back.
+func (t *tracker) release(pr *probe, outcome timing.Outcome) {
+	t.cc.Release(pr.target.String(), outcome)
+}
+
+// due returns the probes whose timeout has passed, split into those that
+// should be retransmitted and those that have used up their retries, and
+// the sent probes whose host has run out of time.
+func (t *tracker) due(now time.Time) (resend, expired, abandoned []*probe) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, pr := range t.pending {
+		if pr.attempts == 0 {
+			continue
+		}
+		if t.hosts.Expired(pr.target.String(), now) {
+			abandoned = append(abandoned, pr)
+			continue
+		}
+		if now.Sub(pr.sentAt) < t.rtt.Backoff(pr.target.String(), pr.attempts) {
+			continue
+		}
+		if pr.attempts > t.retries {
+			delete(t.pending, key)
+			expired = append(expired, pr)
+			continue
+		}
+		resend = append(resend, pr)
+	}
+
+	return resend, expired, abandoned
+}
+
+// abandon reports probes whose host ran out of time before they were
+// answered.
+func (t *tracker) abandon(prs ...*probe) {
+	for _, pr := range prs {
+		if !t.remove(pr) {
+			continue
+		}
+		if pr.attempts > 0 {
+			t.release(pr, timing.Silent)
+		}
+		err := fmt.Errorf("%w after %s", tcpscanner.ErrHostTimeout, t.hosts.Timeout())
+		t.results <- pr.result(tcpscanner.Abandoned, err)
+	}
+}
+
+func (t *tracker) remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// schedule retransmits and expires probes until none are left, then cancels
+// the scan. sent is closed once every probe has been transmitted once.
+func (t *tracker) schedule(ctx context.Context, sent <-chan struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		resend, expired, abandoned := t.due(time.Now())
+		t.abandon(abandoned...)
+		for _, pr := range expired {
+			t.release(pr, timing.Silent)
+			err := fmt.Errorf("no response from %s:%d after %d probe(s)", pr.target, pr.port, pr.attempts)
+			t.results <- pr.result(t.silent, err)
+		}
+		t.send(ctx, resend...)
+
+		select {
+		case <-sent:
+			if t.remaining() == 0 {
+				cancel()
+				return
+			}
+		default:
+		}
+	}
+}
+
+// lastReply is the latest a reply can still resolve a probe whose final
+// first transmission went out at sent: once every retransmission has
+// waited out the longest backoff.
+func (t *tracker) lastReply(sent time.Time) time.Time {
+	return sent.Add(time.Duration(t.retries+1)*t.rtt.Max + scheduleInterval)
+}
+
+// flush reports every probe still outstanding as unanswered.
+func (t *tracker) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, pr := range t.pending {
+		delete(t.pending, key)
+		t.results <- pr.result(t.silent, fmt.Errorf("scan ended before %s:%d answered", pr.target, pr.port))
+	}
+}
@@ -0,0 +1,191 @@
+package synscanner
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	tcpscanner "github.com/CodeZeroSugar/go-scan/internal/scanners/tcp_scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSender records when each port was sent a packet without sending
+// any.
+type fakeSender struct {
+	mu    sync.Mutex
+	sends map[uint16][]time.Time
+}
+
+func (f *fakeSender) Send(ctx context.Context, packets ...*Packet) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sends == nil {
+		f.sends = make(map[uint16][]time.Time)
+	}
+	now := time.Now()
+	for _, p := range packets {
+		f.sends[p.TCPSeg.DstPort] = append(f.sends[p.TCPSeg.DstPort], now)
+	}
+	return len(packets), nil
+}
+
+func (f *fakeSender) BatchSize() int {
+	return MaxBatch
+}
+
+func (f *fakeSender) total() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, times := range f.sends {
+		n += len(times)
+	}
+	return n
+}
+
+func (f *fakeSender) times(port uint16) []time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Time(nil), f.sends[port]...)
+}
+
+// newTestScan builds probes for ports 1000 onwards on 192.0.2.1, which
+// never answers, and returns the channel their results go to.
+func newTestScan(t *testing.T, opts Options, sender packetSender, ports int) (*scan, []*probe, chan tcpscanner.PortScanResults) {
+	t.Helper()
+
+	results := make(chan tcpscanner.PortScanResults, ports)
+	s, err := newScan(opts, sender, results)
+	require.NoError(t, err)
+
+	var list []int
+	for i := range ports {
+		list = append(list, 1000+i)
+	}
+	source := func(net.IP) (net.IP, error) { return net.IP{127, 0, 0, 1}, nil }
+	probes, err := s.build([]net.IP{{192, 0, 2, 1}}, list, source)
+	require.NoError(t, err)
+
+	return s, probes, results
+}
+
+// runTracker sends probes and schedules their retransmissions until every
+// one has a result or a second has passed.
+func runTracker(s *scan, probes []*probe) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	sent := make(chan struct{})
+	close(sent)
+	s.tracker.send(ctx, probes...)
+	s.tracker.schedule(ctx, sent, cancel)
+}
+
+func TestTrackerRetransmits(t *testing.T) {
+	sender := &fakeSender{}
+	s, probes, results := newTestScan(t, Options{ScanType: tcpscanner.SYN, Retries: 2}, sender, 1)
+	s.tracker.rtt.Initial = 40 * time.Millisecond
+	s.tracker.rtt.Min = 10 * time.Millisecond
+
+	runTracker(s, probes)
+
+	// Test: each retransmission waits out twice the previous timeout
+	times := sender.times(1000)
+	require.Len(t, times, 3)
+	assert.GreaterOrEqual(t, times[1].Sub(times[0]), 40*time.Millisecond)
+	assert.Less(t, times[1].Sub(times[0]), 80*time.Millisecond)
+	assert.GreaterOrEqual(t, times[2].Sub(times[1]), 80*time.Millisecond)
+	assert.Less(t, times[2].Sub(times[1]), 160*time.Millisecond)
+
+	// Test: the port is filtered once its retries go unanswered too
+	require.Len(t, results, 1)
+	res := <-results
+	assert.Equal(t, tcpscanner.Filtered, res.State)
+	assert.Equal(t, 3, res.Attempts)
+	assert.Equal(t, 0, s.tracker.remaining())
+}
+
+func TestTrackerSilentState(t *testing.T) {
+	s, probes, results := newTestScan(t, Options{ScanType: tcpscanner.FIN}, &fakeSender{}, 2)
+	s.tracker.rtt.Initial = 10 * time.Millisecond
+	s.tracker.rtt.Min = 10 * time.Millisecond
+
+	runTracker(s, probes)
+
+	// Test: unanswered probes get the scan type's silent state
+	require.Len(t, results, 2)
+	for range 2 {
+		res := <-results
+		assert.Equal(t, tcpscanner.OpenFiltered, res.State)
+		assert.Equal(t, 1, res.Attempts)
+	}
+}
+
+func TestTrackerHostTimeout(t *testing.T) {
+	sender := &fakeSender{}
+	opts := Options{ScanType: tcpscanner.SYN, Retries: 100, HostTimeout: 100 * time.Millisecond}
+	s, probes, results := newTestScan(t, opts, sender, 3)
+	s.tracker.rtt.Initial = 10 * time.Millisecond
+	s.tracker.rtt.Min = 10 * time.Millisecond
+	s.tracker.rtt.Max = 10 * time.Millisecond
+
+	start := time.Now()
+	runTracker(s, probes)
+
+	// Test: ports still retrying when their host runs out of time are
+	// abandoned
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	require.Len(t, results, 3)
+	for range 3 {
+		res := <-results
+		assert.Equal(t, tcpscanner.Abandoned, res.State)
+		assert.ErrorIs(t, res.ErrorInfo, tcpscanner.ErrHostTimeout)
+		assert.Greater(t, res.Attempts, 1)
+	}
+	assert.Equal(t, 0, s.tracker.remaining())
+}
+
+func TestTrackerFlush(t *testing.T) {
+	s, probes, results := newTestScan(t, Options{ScanType: tcpscanner.SYN, Retries: 2}, &fakeSender{}, 4)
+	s.tracker.send(context.Background(), probes[:2]...)
+
+	s.tracker.flush()
+
+	// Test: every pending probe is reported, sent or not
+	assert.Equal(t, 0, s.tracker.remaining())
+	require.Len(t, results, 4)
+	ports := make(map[int]bool)
+	for range 4 {
+		res := <-results
+		assert.Equal(t, tcpscanner.Filtered, res.State)
+		ports[res.Port] = true
+	}
+	assert.Len(t, ports, 4)
+
+	// Test: flushed probes no longer match replies
+	_, ok := s.tracker.resolve(TCPEvent{SrcIP: probes[0].addr, SrcPort: 1000, DstPort: s.srcPort})
+	assert.False(t, ok)
+}
+
+func TestTrackerResolve(t *testing.T) {
+	s, probes, _ := newTestScan(t, Options{ScanType: tcpscanner.SYN}, &fakeSender{}, 2)
+	s.tracker.send(context.Background(), probes[0])
+	reply := func(port uint16) TCPEvent {
+		return TCPEvent{SrcIP: probes[0].addr, SrcPort: port, DstPort: s.srcPort, Time: time.Now()}
+	}
+
+	// Test: a reply resolves its probe once
+	pr, ok := s.tracker.resolve(reply(1000))
+	require.True(t, ok)
+	assert.Equal(t, 1000, pr.port)
+	_, ok = s.tracker.resolve(reply(1000))
+	assert.False(t, ok)
+
+	// Test: probes not sent yet can't be answered
+	_, ok = s.tracker.resolve(reply(1001))
+	assert.False(t, ok)
+	assert.Equal(t, 1, s.tracker.remaining())
+}
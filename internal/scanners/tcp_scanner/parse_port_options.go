@@ -4,15 +4,31 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/CodeZeroSugar/go-scan/internal/config"
+	"github.com/CodeZeroSugar/go-scan/internal/resolver"
 )
 
 type Params struct {
-	Target    string
-	Ports     []string
-	PortMode  PortMode
-	Discovery bool
-	Stats     bool
-	Filtered  bool
+	Target      string
+	Exclude     string
+	ExcludeFile string
+	Ports       []string
+	PortMode    PortMode
+	ScanType    ScanType
+	Discovery   bool
+	Stats       bool
+	Filtered    bool
+	SourceIP    string
+	Interface   string
+	PcapFile    string
+	Timing      config.Timing
+
+	DNSServer     string
+	DNSTimeout    time.Duration
+	ResolveFamily resolver.Family
+	ReverseDNS    bool
 }
 
 type PortMode int
@@ -23,6 +39,19 @@ const (
 	Series
 )
 
+type ScanType int
+
+const (
+	Connect ScanType = iota
+	SYN
+	FIN
+	NULL
+	Xmas
+	ACK
+	Window
+	Maimon
+)
+
 func ParsePortOpts(params Params) ([]int, int, error) {
 	var portLen int
 	var p []int
@@ -36,14 +65,21 @@ func ParsePortOpts(params Params) ([]int, int, error) {
 		}
 		p = append(p, num)
 	case Selection:
-		portLen = len(params.Ports)
+		// Ports listed twice are scanned once; every scan expects one
+		// result per port.
+		seen := make(map[int]bool)
 		for _, n := range params.Ports {
 			num, err := strconv.Atoi(n)
 			if err != nil {
 				return nil, 0, fmt.Errorf("invalid integer assigned to port: %w", err)
 			}
+			if seen[num] {
+				continue
+			}
+			seen[num] = true
 			p = append(p, num)
 		}
+		portLen = len(p)
 	case Series:
 		splitPorts := strings.Split(params.Ports[0], "-")
 		numLow, err := strconv.Atoi(splitPorts[0])
@@ -0,0 +1,26 @@
+package tcpscanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePortOpts(t *testing.T) {
+	// Test: a selection keeps its order
+	ports, portLen, err := ParsePortOpts(Params{PortMode: Selection, Ports: []string{"443", "22", "80"}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{443, 22, 80}, ports)
+	assert.Equal(t, 3, portLen)
+
+	// Test: ports listed twice are only planned once
+	ports, portLen, err = ParsePortOpts(Params{PortMode: Selection, Ports: []string{"22", "80", "22"}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{22, 80}, ports)
+	assert.Equal(t, 2, portLen)
+
+	// Test: ports must be integers
+	_, _, err = ParsePortOpts(Params{PortMode: Selection, Ports: []string{"22", "ssh"}})
+	assert.Error(t, err)
+}
@@ -2,11 +2,19 @@
 package tcpscanner
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net"
+	"sync"
 	"time"
+
+	"github.com/CodeZeroSugar/go-scan/internal/timing"
 )
 
-//go:generate stringer -type=PortState
+const DialTimeout = 2 * time.Second
+
+//go:generate stringer -type=PortState -linecomment
 type PortState int
 
 const (
@@ -14,12 +22,16 @@ const (
 	Closed
 	Filtered
 	Unreachable
+	Unfiltered
+	OpenFiltered // Open|Filtered
+	Abandoned
 )
 
 type PortScanTask struct {
 	TargetIP net.IP
 	Port     int
-	// Timeout  time.Duration
+	// Timeout bounds the connection attempt; 0 means Options.DialTimeout.
+	Timeout time.Duration
 }
 
 type PortScanResults struct {
@@ -27,38 +39,222 @@ type PortScanResults struct {
 	Port      int
 	State     PortState
 	ErrorInfo error
+	OS        string
+	// Attempts is how many probes were sent to the port.
+	Attempts int
 }
 
-func Scan(taskQueue chan PortScanTask, resultQueue chan PortScanResults) {
-	for {
-		task, ok := <-taskQueue
-		if !ok {
-			return
-		}
+// ErrHostTimeout is wrapped by the error of every port abandoned because
+// its host ran out of time.
+var ErrHostTimeout = errors.New("host timeout reached")
 
-		tcpAddrDst := net.TCPAddr{
-			IP:   task.TargetIP,
-			Port: task.Port,
-		}
+type Options struct {
+	// DialTimeout bounds each connection attempt whose task doesn't set a
+	// timeout; 0 means DialTimeout.
+	DialTimeout time.Duration
+	// Limiter paces connection attempts; nil doesn't pace.
+	Limiter *timing.Limiter
+	// Congestion bounds the attempts in flight to each host; nil doesn't.
+	Congestion *timing.Congestion
+	// RTT is fed the round trip of every attempt that got an answer.
+	RTT *timing.RTTEstimator
+	// Hosts abandons a host's remaining ports once its deadline passes.
+	Hosts *timing.HostClock
+	// Retries is how many times a timed out connection is retried before
+	// the port is reported filtered.
+	Retries int
+}
 
-		d := net.Dialer{
-			Timeout: 2 * time.Second,
-		}
+// job is a task and the attempts made at it so far.
+type job struct {
+	task     PortScanTask
+	attempts int
+	timeout  time.Duration
+}
+
+// Scan connects to each task's port from up to workers goroutines and
+// reports one result per task on resultQueue. A task whose connection timed
+// out is queued again with a doubled timeout, up to the dial timeout, until
+// opts.Retries retries have timed out too. New tasks go out ahead of
+// retries, so a slow port doesn't hold up the rest.
+//
+// Scan returns once taskQueue is closed and every task has its result, or
+// once ctx is done. Tasks cut short by ctx produce no result.
+func Scan(ctx context.Context, workers int, taskQueue <-chan PortScanTask, resultQueue chan<- PortScanResults, opts Options) {
+	work := make(chan job)
+	retries := make(chan job)
+	finished := make(chan struct{})
 
-		conn, err := d.Dial("tcp", tcpAddrDst.String())
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range work {
+				res, retry, err := attempt(ctx, &j, opts)
+				if err != nil {
+					return
+				}
+
+				if retry {
+					select {
+					case retries <- j:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
+
+				resultQueue <- res
+				select {
+				case finished <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
 
-		state, resultErr := filterConnState(err)
-		if conn != nil {
-			conn.Close()
+	defer func() {
+		close(work)
+		wg.Wait()
+	}()
+
+	var queue []job
+	var next *job
+	in := taskQueue
+	busy := 0
+	for in != nil || next != nil || len(queue) > 0 || busy > 0 {
+		var out chan job
+		var send job
+		switch {
+		case next != nil:
+			out, send = work, *next
+		case len(queue) > 0:
+			out, send = work, queue[0]
+		}
+		recv := in
+		if next != nil {
+			recv = nil
 		}
 
-		results := PortScanResults{
-			TargetIP:  task.TargetIP,
-			Port:      tcpAddrDst.Port,
-			State:     state,
-			ErrorInfo: resultErr,
+		select {
+		case task, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			next = &job{task: task, timeout: task.Timeout}
+		case out <- send:
+			if next != nil {
+				next = nil
+			} else {
+				queue = queue[1:]
+			}
+			busy++
+		case j := <-retries:
+			busy--
+			queue = append(queue, j)
+		case <-finished:
+			busy--
+		case <-ctx.Done():
+			return
 		}
+	}
+}
+
+// attempt dials j's port once. It reports the port's result, or retry if
+// the connection timed out and j has retries left, in which case j is
+// ready for its next attempt. It only fails if ctx is done.
+func attempt(ctx context.Context, j *job, opts Options) (PortScanResults, bool, error) {
+	task := j.task
+	host := task.TargetIP.String()
+
+	ceiling := opts.DialTimeout
+	if ceiling <= 0 {
+		ceiling = DialTimeout
+	}
+	if j.timeout <= 0 {
+		j.timeout = ceiling
+	}
+
+	if opts.Hosts.Expired(host, time.Now()) {
+		return abandoned(task, opts.Hosts, j.attempts), false, nil
+	}
+
+	if err := opts.Congestion.Acquire(ctx, host); err != nil {
+		return PortScanResults{}, false, err
+	}
+	if err := opts.Limiter.Wait(ctx); err != nil {
+		opts.Congestion.Release(host, timing.Silent)
+		return PortScanResults{}, false, err
+	}
+
+	j.attempts++
+	state, rtt, err := dial(ctx, task, j.timeout, opts.Hosts.Start(host))
+	if ctx.Err() != nil {
+		opts.Congestion.Release(host, timing.Silent)
+		return PortScanResults{}, false, ctx.Err()
+	}
+
+	outcome := timing.Answered
+	switch {
+	case state == Filtered:
+		outcome = timing.Silent
+	case j.attempts > 1:
+		outcome = timing.Recovered
+	}
+	opts.Congestion.Release(host, outcome)
+
+	if state == Filtered && opts.Hosts.Expired(host, time.Now()) {
+		return abandoned(task, opts.Hosts, j.attempts), false, nil
+	}
+	if state != Filtered && opts.RTT != nil {
+		opts.RTT.Observe(host, rtt)
+	}
+
+	if state == Filtered && j.attempts <= opts.Retries {
+		j.timeout = min(2*j.timeout, max(j.timeout, ceiling))
+		return PortScanResults{}, true, nil
+	}
+	return PortScanResults{
+		TargetIP:  task.TargetIP,
+		Port:      task.Port,
+		State:     state,
+		ErrorInfo: err,
+		Attempts:  j.attempts,
+	}, false, nil
+}
+
+func dial(ctx context.Context, task PortScanTask, timeout time.Duration, deadline time.Time) (PortState, time.Duration, error) {
+	tcpAddrDst := net.TCPAddr{
+		IP:   task.TargetIP,
+		Port: task.Port,
+	}
+
+	d := net.Dialer{
+		Timeout:  timeout,
+		Deadline: deadline,
+	}
+
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", tcpAddrDst.String())
+	rtt := time.Since(start)
+
+	state, resultErr := filterConnState(err)
+	if conn != nil {
+		conn.Close()
+	}
+
+	return state, rtt, resultErr
+}
 
-		resultQueue <- results
+func abandoned(task PortScanTask, hosts *timing.HostClock, attempts int) PortScanResults {
+	return PortScanResults{
+		TargetIP:  task.TargetIP,
+		Port:      task.Port,
+		State:     Abandoned,
+		ErrorInfo: fmt.Errorf("%w after %s", ErrHostTimeout, hosts.Timeout()),
+		Attempts:  attempts,
 	}
 }
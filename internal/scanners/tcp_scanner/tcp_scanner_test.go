@@ -0,0 +1,72 @@
+package tcpscanner
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scan runs tasks through Scan and returns their results in the order
+// they were reported.
+func scan(ctx context.Context, workers int, opts Options, tasks ...PortScanTask) []PortScanResults {
+	taskQueue := make(chan PortScanTask, len(tasks))
+	for _, task := range tasks {
+		taskQueue <- task
+	}
+	close(taskQueue)
+
+	resultQueue := make(chan PortScanResults, len(tasks))
+	Scan(ctx, workers, taskQueue, resultQueue, opts)
+	close(resultQueue)
+
+	var results []PortScanResults
+	for res := range resultQueue {
+		results = append(results, res)
+	}
+	return results
+}
+
+func TestScanRetries(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// A nanosecond is too short for any connection, so each attempt times
+	// out until the doubling timeout is long enough to connect.
+	task := PortScanTask{
+		TargetIP: net.ParseIP("127.0.0.1"),
+		Port:     ln.Addr().(*net.TCPAddr).Port,
+		Timeout:  time.Nanosecond,
+	}
+
+	// Test: without retries the first timeout is final
+	results := scan(context.Background(), 1, Options{DialTimeout: time.Second}, task)
+	require.Len(t, results, 1)
+	assert.Equal(t, Filtered, results[0].State)
+	assert.Equal(t, 1, results[0].Attempts)
+
+	// Test: retries back off until the port answers
+	results = scan(context.Background(), 1, Options{DialTimeout: time.Second, Retries: 40}, task)
+	require.Len(t, results, 1)
+	assert.Equal(t, Open, results[0].State)
+	assert.Greater(t, results[0].Attempts, 1)
+	assert.NoError(t, results[0].ErrorInfo)
+
+	// Test: a retried port goes back in the queue instead of holding its
+	// worker, so the next task is answered first
+	other := task
+	other.Timeout = 0
+	results = scan(context.Background(), 1, Options{DialTimeout: time.Second, Retries: 40}, task, other)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Attempts)
+	assert.Greater(t, results[1].Attempts, 1)
+
+	// Test: a cancelled scan gives no result
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Empty(t, scan(ctx, 1, Options{Retries: 40}, task))
+}
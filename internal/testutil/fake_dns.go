@@ -0,0 +1,68 @@
+// Package testutil provides helpers shared by GoScan's tests. Only test
+// files import it
+package testutil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// FakeDNS runs a DNS server over UDP until the test ends and returns its
+// address. It resolves db01.internal to 10.2.3.4 and 2001:db8::4, answers
+// PTR queries for 10.2.3.4 with it and fails every other name.
+func FakeDNS(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			q := query.Questions[0]
+
+			reply := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+				Questions: query.Questions,
+			}
+			hdr := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60}
+			switch {
+			case q.Type == dnsmessage.TypePTR && q.Name.String() == "4.3.2.10.in-addr.arpa.":
+				reply.Answers = append(reply.Answers, dnsmessage.Resource{
+					Header: hdr,
+					Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("db01.internal.")},
+				})
+			case q.Name.String() != "db01.internal.":
+				reply.RCode = dnsmessage.RCodeNameError
+			case q.Type == dnsmessage.TypeA:
+				reply.Answers = append(reply.Answers, dnsmessage.Resource{
+					Header: hdr,
+					Body:   &dnsmessage.AResource{A: [4]byte{10, 2, 3, 4}},
+				})
+			case q.Type == dnsmessage.TypeAAAA:
+				reply.Answers = append(reply.Answers, dnsmessage.Resource{
+					Header: hdr,
+					Body:   &dnsmessage.AAAAResource{AAAA: [16]byte(net.ParseIP("2001:db8::4"))},
+				})
+			}
+
+			out, err := reply.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(out, from)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
@@ -0,0 +1,189 @@
+package timing
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	InitialWindow = 10
+	MinWindow     = 1
+)
+
+// Outcome is what became of a probe, as far as congestion is concerned.
+type Outcome int
+
+const (
+	// Answered probes got a reply to their first transmission.
+	Answered Outcome = iota
+	// Recovered probes were only answered after a retransmission, so an
+	// earlier copy or its reply was lost.
+	Recovered
+	// Silent probes never got a reply. That is what a filtered port looks
+	// like, so it says nothing about congestion.
+	Silent
+)
+
+// Congestion bounds the probes in flight to each host with an AIMD window
+// in the style of TCP congestion control. Windows start at InitialWindow,
+// double every round trip while in slow start and then grow by one per
+// window of replies. Losses halve them, at most once per window so a burst
+// of drops isn't punished several times over.
+//
+// Only recovered probes count as losses. A probe that is never answered is
+// most likely a filtered port, even on a host that answers others, and
+// shrinking the window for it would slow a firewalled host to a crawl.
+// Silence still grows the window of a host that has never answered, so a
+// fully filtered host is scanned as fast as the window allows, but once a
+// host has answered only replies grow it.
+//
+// A nil Congestion puts no bound on probes in flight.
+type Congestion struct {
+	Min int
+	Max int
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	hosts map[string]*window
+	ready chan struct{}
+}
+
+type window struct {
+	cwnd     float64
+	ssthresh float64
+	inFlight int
+	answered bool
+	sinceCut int
+}
+
+// NewCongestion returns windows capped at max probes in flight per host.
+func NewCongestion(max int) *Congestion {
+	c := &Congestion{
+		Min:   MinWindow,
+		Max:   max,
+		hosts: make(map[string]*window),
+		ready: make(chan struct{}, 1),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *Congestion) host(host string) *window {
+	w, ok := c.hosts[host]
+	if !ok {
+		cwnd := float64(min(InitialWindow, c.Max))
+		w = &window{cwnd: cwnd, ssthresh: float64(c.Max), sinceCut: int(cwnd)}
+		c.hosts[host] = w
+	}
+	return w
+}
+
+// Acquire blocks until host has room for another probe and takes it, or
+// until ctx is done.
+func (c *Congestion) Acquire(ctx context.Context, host string) error {
+	if c == nil {
+		return ctx.Err()
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cond.Broadcast()
+	})
+	defer stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := c.host(host)
+	for float64(w.inFlight) >= w.cwnd {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.cond.Wait()
+	}
+	w.inFlight++
+	return nil
+}
+
+// TryAcquire takes room for a probe to host if there is any.
+func (c *Congestion) TryAcquire(host string) bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := c.host(host)
+	if float64(w.inFlight) >= w.cwnd {
+		return false
+	}
+	w.inFlight++
+	return true
+}
+
+// Force takes room for a probe to host even if its window is full, for
+// callers that have to keep up a minimum rate.
+func (c *Congestion) Force(host string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.host(host).inFlight++
+}
+
+// Release returns a probe's room to host and adjusts its window by what
+// became of the probe.
+func (c *Congestion) Release(host string, outcome Outcome) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	w := c.host(host)
+	w.inFlight--
+	w.sinceCut++
+
+	switch {
+	case outcome == Silent && w.answered:
+		// A filtered port, not a loss: leave the window alone.
+	case outcome == Recovered:
+		if float64(w.sinceCut) >= w.cwnd {
+			w.ssthresh = max(w.cwnd/2, float64(c.Min))
+			w.cwnd = w.ssthresh
+			w.sinceCut = 0
+		}
+	case w.cwnd < w.ssthresh:
+		w.cwnd++
+	default:
+		w.cwnd += 1 / w.cwnd
+	}
+	w.cwnd = min(max(w.cwnd, float64(c.Min)), float64(c.Max))
+
+	if outcome != Silent {
+		w.answered = true
+	}
+	c.mu.Unlock()
+
+	c.cond.Broadcast()
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready delivers a value after releases, for callers polling TryAcquire
+// across several hosts instead of blocking in Acquire.
+func (c *Congestion) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Window returns how many probes may currently be in flight to host.
+func (c *Congestion) Window(host string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.host(host).cwnd)
+}
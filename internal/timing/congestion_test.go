@@ -0,0 +1,64 @@
+package timing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCongestion(t *testing.T) {
+	c := NewCongestion(100)
+
+	// Test: windows start at InitialWindow and bound TryAcquire
+	for i := 0; i < InitialWindow; i++ {
+		assert.True(t, c.TryAcquire("a"))
+	}
+	assert.False(t, c.TryAcquire("a"))
+	assert.True(t, c.TryAcquire("b"))
+
+	// Test: slow start grows the window by one per reply
+	for i := 0; i < InitialWindow; i++ {
+		c.Release("a", Answered)
+	}
+	assert.Equal(t, 2*InitialWindow, c.Window("a"))
+
+	// Test: a loss halves the window, but only once per window of probes
+	c.Release("a", Recovered)
+	assert.Equal(t, InitialWindow, c.Window("a"))
+	c.Release("a", Silent)
+	assert.Equal(t, InitialWindow, c.Window("a"))
+
+	// Test: past the threshold the window grows by about one per window of
+	// replies
+	for i := 0; i < 2*InitialWindow; i++ {
+		c.Release("a", Answered)
+	}
+	assert.Equal(t, InitialWindow+1, c.Window("a"))
+
+	// Test: silence from a host that never answered isn't congestion
+	c.Release("b", Silent)
+	assert.Equal(t, InitialWindow+1, c.Window("b"))
+
+	// Test: a firewalled host that closes some ports and drops the rest
+	// keeps growing its window instead of collapsing
+	mixed := NewCongestion(100)
+	for i := 0; i < 2000; i++ {
+		require.True(t, mixed.TryAcquire("c"))
+		if i%10 == 0 {
+			mixed.Release("c", Answered)
+		} else {
+			mixed.Release("c", Silent)
+		}
+	}
+	assert.Equal(t, 100, mixed.Window("c"))
+
+	// Test: windows stay within Min and Max
+	small := NewCongestion(4)
+	assert.Equal(t, 4, small.Window("a"))
+	small.Release("a", Answered)
+	for i := 0; i < 20; i++ {
+		small.Release("a", Recovered)
+	}
+	assert.Equal(t, MinWindow, small.Window("a"))
+}
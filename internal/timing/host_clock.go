@@ -0,0 +1,57 @@
+package timing
+
+import (
+	"sync"
+	"time"
+)
+
+// HostClock gives every host an overall deadline, counted from the first
+// time it is probed. A nil HostClock or a zero timeout never expires.
+type HostClock struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	started map[string]time.Time
+}
+
+func NewHostClock(timeout time.Duration) *HostClock {
+	return &HostClock{timeout: timeout, started: make(map[string]time.Time)}
+}
+
+// Start starts host's clock if it isn't running yet and returns its
+// deadline, or the zero time if there is none.
+func (c *HostClock) Start(host string) time.Time {
+	if c == nil || c.timeout <= 0 {
+		return time.Time{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start, ok := c.started[host]
+	if !ok {
+		start = time.Now()
+		c.started[host] = start
+	}
+	return start.Add(c.timeout)
+}
+
+// Expired reports whether host's clock was started and has run out by now.
+func (c *HostClock) Expired(host string, now time.Time) bool {
+	if c == nil || c.timeout <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start, ok := c.started[host]
+	return ok && now.Sub(start) >= c.timeout
+}
+
+func (c *HostClock) Timeout() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.timeout
+}
@@ -0,0 +1,29 @@
+package timing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostClock(t *testing.T) {
+	c := NewHostClock(time.Minute)
+	now := time.Now()
+
+	// Test: a host's clock only runs once it has been started
+	assert.False(t, c.Expired("a", now.Add(time.Hour)))
+
+	// Test: the deadline counts from the first start
+	deadline := c.Start("a")
+	assert.WithinDuration(t, now.Add(time.Minute), deadline, time.Second)
+	assert.Equal(t, deadline, c.Start("a"))
+	assert.False(t, c.Expired("a", now))
+	assert.True(t, c.Expired("a", now.Add(2*time.Minute)))
+
+	// Test: no timeout never expires
+	var none *HostClock
+	assert.True(t, none.Start("a").IsZero())
+	assert.False(t, none.Expired("a", now.Add(time.Hour)))
+	assert.True(t, NewHostClock(0).Start("a").IsZero())
+}
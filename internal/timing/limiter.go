@@ -0,0 +1,97 @@
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BurstWindow is how much sending a limiter lets accumulate while idle, so
+// a quiet spell never turns into a large burst.
+const BurstWindow = 10 * time.Millisecond
+
+// Limiter is a token bucket shared by every scan engine so that all probes
+// of a scan, whatever sends them, count towards one packets-per-second
+// rate; a rate of 0 means no cap. It also carries the minimum rate scans
+// should keep up, which it leaves to them to enforce. A nil Limiter never
+// waits.
+type Limiter struct {
+	mu     sync.Mutex
+	min    float64
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter capped at max probes per second that asks
+// scans to send at least min.
+func NewLimiter(min, max float64) *Limiter {
+	return &Limiter{min: min, rate: max}
+}
+
+// Wait blocks until one probe may be sent or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n probes may be sent or ctx is done. Callers are
+// served in the order they arrive, each reserving its tokens before
+// sleeping.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if l.last.IsZero() {
+		l.tokens = l.burst()
+	} else {
+		l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst())
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens / l.rate
+	l.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(deficit * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) burst() float64 {
+	return max(1, l.rate*BurstWindow.Seconds())
+}
+
+// Rate returns the most probes per second, or 0 if unlimited.
+func (l *Limiter) Rate() float64 {
+	if l == nil {
+		return 0
+	}
+	return l.rate
+}
+
+// Min returns the fewest probes per second scans should send.
+func (l *Limiter) Min() float64 {
+	if l == nil {
+		return 0
+	}
+	return l.min
+}
@@ -0,0 +1,53 @@
+package timing
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	// Test: a nil limiter never waits
+	var none *Limiter
+	start := time.Now()
+	assert.NoError(t, none.WaitN(context.Background(), 1000))
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+	assert.Equal(t, 0.0, none.Rate())
+
+	// Test: waiters sharing a limiter are held to its rate together
+	l := NewLimiter(0, 1000)
+	var wg sync.WaitGroup
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, l.Wait(context.Background()))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 180*time.Millisecond)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+
+	// Test: a cancelled wait returns early
+	l = NewLimiter(0, 1)
+	assert.NoError(t, l.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	assert.ErrorIs(t, l.WaitN(ctx, 10), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// Test: the minimum rate is carried for scans to keep up, without
+	// capping the rate
+	l = NewLimiter(10, 0)
+	assert.Equal(t, 10.0, l.Min())
+	assert.Equal(t, 0.0, l.Rate())
+	assert.Equal(t, 0.0, none.Min())
+}
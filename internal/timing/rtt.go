@@ -0,0 +1,90 @@
+// Package timing provides the round-trip estimation and pacing shared by
+// GoScan's scan engines
+package timing
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	InitialTimeout = 1 * time.Second
+	MinTimeout     = 100 * time.Millisecond
+	MaxTimeout     = 10 * time.Second
+)
+
+// RTTEstimator tracks a smoothed round-trip time per host as described in
+// RFC 6298 and derives a retransmission timeout from it.
+type RTTEstimator struct {
+	Initial time.Duration
+	Min     time.Duration
+	Max     time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*rttState
+}
+
+type rttState struct {
+	srtt   time.Duration
+	rttvar time.Duration
+}
+
+func NewRTTEstimator() *RTTEstimator {
+	return &RTTEstimator{
+		Initial: InitialTimeout,
+		Min:     MinTimeout,
+		Max:     MaxTimeout,
+		hosts:   make(map[string]*rttState),
+	}
+}
+
+func (e *RTTEstimator) Observe(host string, rtt time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st, ok := e.hosts[host]
+	if !ok {
+		e.hosts[host] = &rttState{srtt: rtt, rttvar: rtt / 2}
+		return
+	}
+
+	diff := st.srtt - rtt
+	if diff < 0 {
+		diff = -diff
+	}
+	st.rttvar = (3*st.rttvar + diff) / 4
+	st.srtt = (7*st.srtt + rtt) / 8
+}
+
+// Timeout returns how long to wait for a reply from host before
+// retransmitting, or Initial if no round trip has been observed yet.
+func (e *RTTEstimator) Timeout(host string) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st, ok := e.hosts[host]
+	if !ok {
+		return e.Initial
+	}
+
+	return e.clamp(st.srtt + 4*st.rttvar)
+}
+
+// Backoff doubles the host's timeout for every retransmission already made.
+func (e *RTTEstimator) Backoff(host string, attempts int) time.Duration {
+	timeout := e.Timeout(host)
+	for i := 1; i < attempts && timeout < e.Max; i++ {
+		timeout *= 2
+	}
+	return e.clamp(timeout)
+}
+
+func (e *RTTEstimator) clamp(d time.Duration) time.Duration {
+	if d < e.Min {
+		return e.Min
+	}
+	if d > e.Max {
+		return e.Max
+	}
+	return d
+}
@@ -0,0 +1,34 @@
+package timing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTTEstimator(t *testing.T) {
+	e := NewRTTEstimator()
+
+	// Test: unknown host uses the initial timeout
+	assert.Equal(t, InitialTimeout, e.Timeout("10.0.0.1"))
+
+	// Test: first sample sets srtt and rttvar
+	e.Observe("10.0.0.1", 20*time.Millisecond)
+	assert.Equal(t, MinTimeout, e.Timeout("10.0.0.1"))
+
+	e.Observe("10.0.0.2", 200*time.Millisecond)
+	assert.Equal(t, 600*time.Millisecond, e.Timeout("10.0.0.2"))
+
+	// Test: steady samples shrink the variance
+	for i := 0; i < 20; i++ {
+		e.Observe("10.0.0.2", 200*time.Millisecond)
+	}
+	assert.Less(t, e.Timeout("10.0.0.2"), 300*time.Millisecond)
+
+	// Test: backoff doubles per attempt and is capped
+	assert.Equal(t, InitialTimeout, e.Backoff("10.0.0.3", 1))
+	assert.Equal(t, 2*InitialTimeout, e.Backoff("10.0.0.3", 2))
+	assert.Equal(t, 4*InitialTimeout, e.Backoff("10.0.0.3", 3))
+	assert.Equal(t, MaxTimeout, e.Backoff("10.0.0.3", 10))
+}